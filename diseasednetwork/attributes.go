@@ -0,0 +1,90 @@
+package diseasednetwork
+
+// NodeAttributes describes how one node differs from the others
+type NodeAttributes struct {
+	// Susceptibility multiplies the chance of the node being infected
+	Susceptibility float32
+	// Infectiousness multiplies the chance of the node infecting each of its neighbors
+	Infectiousness float32
+	// Group is a label such as an age group that results can be broken down by
+	Group int
+}
+
+// DefaultAttributes returns attributes for numNodes nodes that are all in group 0 and have
+// multipliers of 1, which is how nodes behave if no attributes are set
+func DefaultAttributes(numNodes int) []NodeAttributes {
+	attributes := make([]NodeAttributes, numNodes)
+	for node := range attributes {
+		attributes[node] = NodeAttributes{Susceptibility: 1, Infectiousness: 1}
+	}
+	return attributes
+}
+
+// SetNodeAttributes gives every node its own attributes. There must be one entry for each node,
+// and the slice must not be modified while the network or its copies are in use.
+// nil makes every node the same.
+func (n *Network) SetNodeAttributes(attributes []NodeAttributes) {
+	if attributes != nil && len(attributes) != n.NumNodes() {
+		panic("There must be attributes for every node!")
+	}
+	n.attributes = attributes
+}
+
+// NodeAttributes returns the attributes of every node or nil if they haven't been set.
+// The slice must not be modified.
+func (n Network) NodeAttributes() []NodeAttributes {
+	return n.attributes
+}
+
+// Group returns the group node belongs to. It is 0 if no attributes have been set.
+func (n Network) Group(node int) int {
+	if n.attributes == nil {
+		return 0
+	}
+	return n.attributes[node].Group
+}
+
+// SetNodeAttributes gives every node its own attributes. See Network.SetNodeAttributes.
+// Attributes set on the underlying network before the DiseasedNetwork is made are already in
+// place when the initial infections are chosen.
+func (n *DiseasedNetwork) SetNodeAttributes(attributes []NodeAttributes) {
+	n.adjMat.SetNodeAttributes(attributes)
+	n.maxMultiplier = maxMultiplier(attributes)
+}
+
+// Group returns the group node belongs to. It is 0 if no attributes have been set.
+func (n *DiseasedNetwork) Group(node int) int {
+	return n.adjMat.Group(node)
+}
+
+// maxMultiplier is the most attributes can multiply the chance of infection by
+func maxMultiplier(attributes []NodeAttributes) float32 {
+	if attributes == nil {
+		return 1
+	}
+	maxSusceptibility := float32(0)
+	maxInfectiousness := float32(0)
+	for _, a := range attributes {
+		if a.Susceptibility > maxSusceptibility {
+			maxSusceptibility = a.Susceptibility
+		}
+		if a.Infectiousness > maxInfectiousness {
+			maxInfectiousness = a.Infectiousness
+		}
+	}
+	return maxSusceptibility * maxInfectiousness
+}
+
+// applyAttributes scales p by the infectiousness of infector and the susceptibility of
+// atRiskNode. The result is capped at 1.
+func (n *DiseasedNetwork) applyAttributes(p float32, infector, atRiskNode int) float32 {
+	attributes := n.adjMat.attributes
+	if attributes == nil {
+		return p
+	}
+	p *= attributes[infector].Infectiousness * attributes[atRiskNode].Susceptibility
+	if p > 1 {
+		return 1
+	}
+	return p
+}
@@ -0,0 +1,59 @@
+package diseasednetwork
+
+import (
+	"math"
+	"testing"
+)
+
+// TestAttributes gives the pairs from makePairs their own susceptibility and infectiousness
+func TestAttributes(t *testing.T) {
+	for _, engine := range []Engine{DiscreteEngine, GillespieEngine} {
+		// the discrete engine gives each infected node one chance to infect its partner and
+		// the Gillespie engine races infection against recovery
+		timeToR, numSteps := 0.0, 1
+		if engine == GillespieEngine {
+			timeToR, numSteps = 4, 100
+		}
+		net := makePairs(t, 4000, 1, SIRModel(NewFixedDuration(int16(timeToR))), engine)
+		attributes := DefaultAttributes(net.NumNodes())
+		for pair := 0; pair < net.NumNodes()/2; pair++ {
+			if pair%2 == 0 {
+				attributes[2*pair+1].Susceptibility = 0
+				attributes[2*pair+1].Group = 1
+			} else {
+				attributes[2*pair].Infectiousness = 2.5
+			}
+		}
+		net.SetNodeAttributes(attributes)
+		for step := 0; step < numSteps; step++ {
+			net.Step()
+		}
+		light, heavy := transmittedFraction(&net)
+		if light != 0 {
+			t.Errorf("%v: expected nodes with no susceptibility to never be infected, found %f infected",
+				engine, light)
+		}
+		expected := .5
+		if engine == GillespieEngine {
+			beta := transmissionRate(.5)
+			expected = beta / (beta + 1/timeToR)
+		}
+		if math.Abs(heavy-expected) > .03 {
+			t.Errorf("%v: expected %f of the pairs with an infectious node to transmit, found %f",
+				engine, expected, heavy)
+		}
+		if net.Group(1) != 1 || net.Group(3) != 0 {
+			t.Errorf("Expected groups 1 and 0, found %d and %d", net.Group(1), net.Group(3))
+		}
+	}
+}
+
+func TestAttributesLength(t *testing.T) {
+	net := makePairs(t, 2, 1, SIRModel(NewFixedDuration(0)), DiscreteEngine)
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected attributes for the wrong number of nodes to panic")
+		}
+	}()
+	net.SetNodeAttributes(DefaultAttributes(3))
+}
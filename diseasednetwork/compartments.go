@@ -0,0 +1,226 @@
+package diseasednetwork
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+)
+
+// Compartment is one of the states a node can be in
+type Compartment struct {
+	Name string
+	// Susceptible nodes can be infected by their infectious neighbors
+	Susceptible bool
+	// Infectious nodes can infect their susceptible neighbors
+	Infectious bool
+	// Infected compartments make up an InfectionCase. A case starts when a node enters an infected
+	// compartment from one that isn't and it resolves when the node leaves the infected compartments.
+	Infected bool
+	// Dead nodes lose all of their edges at the end of the step they enter the compartment in
+	Dead bool
+	// Duration is how long nodes stay in the compartment. Nodes never leave on their own if it is nil.
+	Duration DurationDistribution
+	// Next lists the compartments nodes can move to when their time is up
+	Next []Outcome
+}
+
+// Outcome is a compartment a node may move to and the probability that it does
+type Outcome struct {
+	Compartment uint8
+	Probability float64
+}
+
+// CompartmentalModel describes how a disease moves nodes between compartments.
+// Every node starts in compartment 0.
+type CompartmentalModel struct {
+	Compartments []Compartment
+	// Exposure is the compartment susceptible nodes move to when they are infected
+	Exposure uint8
+	// Seed is the compartment the initial infections are put in
+	Seed uint8
+	// Immune is the compartment vaccinated nodes are moved to. Vaccines do nothing if it is
+	// a susceptible compartment, which it is if it is left as compartment 0.
+	Immune uint8
+}
+
+// standardCompartments returns the compartments of the built in models without any transitions.
+// The built in models all use this layout so that StateS, StateE, StateI, StateR and StateD can
+// be used with any of them. Compartments that a model doesn't use stay empty.
+func standardCompartments() []Compartment {
+	return []Compartment{
+		StateS: {Name: "S", Susceptible: true},
+		StateE: {Name: "E", Infected: true},
+		StateI: {Name: "I", Infected: true, Infectious: true},
+		StateR: {Name: "R"},
+		StateD: {Name: "D", Dead: true},
+	}
+}
+
+// moveTo is the Next of a compartment that nodes always leave for compartment
+func moveTo(compartment uint8) []Outcome {
+	return []Outcome{{Compartment: compartment, Probability: 1}}
+}
+
+// SISModel returns a model where infected nodes become susceptible again after timeToS
+func SISModel(timeToS DurationDistribution) CompartmentalModel {
+	compartments := standardCompartments()
+	compartments[StateI].Duration = timeToS
+	compartments[StateI].Next = moveTo(StateS)
+	return CompartmentalModel{Compartments: compartments, Exposure: StateI, Seed: StateI, Immune: StateR}
+}
+
+// SIRModel returns a model where infected nodes recover after timeToR and are then immune
+func SIRModel(timeToR DurationDistribution) CompartmentalModel {
+	compartments := standardCompartments()
+	compartments[StateI].Duration = timeToR
+	compartments[StateI].Next = moveTo(StateR)
+	return CompartmentalModel{Compartments: compartments, Exposure: StateI, Seed: StateI, Immune: StateR}
+}
+
+// SIRSModel returns a model where infected nodes recover after timeToR and stay immune for timeToS
+func SIRSModel(timeToR, timeToS DurationDistribution) CompartmentalModel {
+	model := SIRModel(timeToR)
+	model.Compartments[StateR].Duration = timeToS
+	model.Compartments[StateR].Next = moveTo(StateS)
+	return model
+}
+
+// SEIRModel returns a model where infected nodes are exposed but not infectious for timeToI,
+// infectious for timeToR and then immune
+func SEIRModel(timeToI, timeToR DurationDistribution) CompartmentalModel {
+	compartments := standardCompartments()
+	compartments[StateE].Duration = timeToI
+	compartments[StateE].Next = moveTo(StateI)
+	compartments[StateI].Duration = timeToR
+	compartments[StateI].Next = moveTo(StateR)
+	return CompartmentalModel{Compartments: compartments, Exposure: StateE, Seed: StateI, Immune: StateR}
+}
+
+// SEIRSModel is SEIRModel, but immunity only lasts for timeToS
+func SEIRSModel(timeToI, timeToR, timeToS DurationDistribution) CompartmentalModel {
+	model := SEIRModel(timeToI, timeToR)
+	model.Compartments[StateR].Duration = timeToS
+	model.Compartments[StateR].Next = moveTo(StateS)
+	return model
+}
+
+// SEIRDModel is SEIRModel, but each infectious node dies instead of recovering with probability
+// fatality. Dead nodes lose all of their edges.
+func SEIRDModel(timeToI, timeToR DurationDistribution, fatality float64) CompartmentalModel {
+	model := SEIRModel(timeToI, timeToR)
+	model.Compartments[StateI].Next = []Outcome{
+		{Compartment: StateR, Probability: 1 - fatality},
+		{Compartment: StateD, Probability: fatality},
+	}
+	return model
+}
+
+// validate returns an error describing the first problem with m
+func (m *CompartmentalModel) validate() error {
+	numCompartments := len(m.Compartments)
+	if numCompartments == 0 {
+		return errors.New("a model needs at least one compartment")
+	}
+	if numCompartments > math.MaxUint8+1 {
+		return fmt.Errorf("a model can have at most %d compartments, found %d", math.MaxUint8+1, numCompartments)
+	}
+	if int(m.Exposure) >= numCompartments {
+		return fmt.Errorf("exposure compartment %d doesn't exist", m.Exposure)
+	}
+	if int(m.Seed) >= numCompartments {
+		return fmt.Errorf("seed compartment %d doesn't exist", m.Seed)
+	}
+	if int(m.Immune) >= numCompartments {
+		return fmt.Errorf("immune compartment %d doesn't exist", m.Immune)
+	}
+	for i, c := range m.Compartments {
+		if c.Duration == nil {
+			if len(c.Next) > 0 {
+				return fmt.Errorf("compartment %d (%s) has outcomes but no duration", i, c.Name)
+			}
+			continue
+		}
+		if len(c.Next) == 0 {
+			return fmt.Errorf("compartment %d (%s) has a duration but no outcomes", i, c.Name)
+		}
+		total := 0.0
+		for _, outcome := range c.Next {
+			if int(outcome.Compartment) >= numCompartments {
+				return fmt.Errorf("compartment %d (%s) leads to compartment %d, which doesn't exist",
+					i, c.Name, outcome.Compartment)
+			}
+			if outcome.Probability < 0 {
+				return fmt.Errorf("compartment %d (%s) has a negative probability", i, c.Name)
+			}
+			total += outcome.Probability
+		}
+		if math.Abs(total-1) > 1e-9 {
+			return fmt.Errorf("the probabilities of compartment %d (%s) add up to %f instead of 1",
+				i, c.Name, total)
+		}
+	}
+	return nil
+}
+
+// compiledModel is a validated CompartmentalModel with lookup tables for the properties of its
+// compartments. It is never modified, so copies of a disease can share it.
+type compiledModel struct {
+	CompartmentalModel
+	susceptible []bool
+	infectious  []bool
+	infected    []bool
+	// infectiousStates and deadStates list the compartments with those properties
+	infectiousStates []uint8
+	deadStates       []uint8
+}
+
+// compileModel validates model and builds its lookup tables. The model's slices are copied so
+// that later changes to them don't affect the disease.
+func compileModel(model CompartmentalModel) (*compiledModel, error) {
+	if err := model.validate(); err != nil {
+		return nil, err
+	}
+	compartments := make([]Compartment, len(model.Compartments))
+	copy(compartments, model.Compartments)
+	model.Compartments = compartments
+
+	m := compiledModel{
+		CompartmentalModel: model,
+		susceptible:        make([]bool, len(compartments)),
+		infectious:         make([]bool, len(compartments)),
+		infected:           make([]bool, len(compartments)),
+	}
+	for i := range compartments {
+		c := &compartments[i]
+		c.Next = append([]Outcome(nil), c.Next...)
+		m.susceptible[i] = c.Susceptible
+		m.infectious[i] = c.Infectious
+		m.infected[i] = c.Infected
+		if c.Infectious {
+			m.infectiousStates = append(m.infectiousStates, uint8(i))
+		}
+		if c.Dead {
+			m.deadStates = append(m.deadStates, uint8(i))
+		}
+	}
+	return &m, nil
+}
+
+// nextCompartment chooses where a node leaving compartment goes. Random numbers are only
+// used if there is more than one possibility.
+func (m *CompartmentalModel) nextCompartment(compartment uint8, rand *rand.Rand) uint8 {
+	next := m.Compartments[compartment].Next
+	if len(next) == 1 {
+		return next[0].Compartment
+	}
+	r := rand.Float64()
+	for _, outcome := range next {
+		if r < outcome.Probability {
+			return outcome.Compartment
+		}
+		r -= outcome.Probability
+	}
+	// the probabilities can add up to slightly less than 1
+	return next[len(next)-1].Compartment
+}
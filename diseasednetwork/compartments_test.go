@@ -0,0 +1,152 @@
+package diseasednetwork
+
+import (
+	"math"
+	"testing"
+)
+
+// runModel spreads a disease following model across a complete network for numSteps steps
+func runModel(t *testing.T, model CompartmentalModel, infectionProbability float32, numNodes, numSteps int) (DiseasedNetwork, Disease) {
+	t.Helper()
+	disease, err := NewCompartmentalDisease(model, infectionProbability, NewInfectN(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	adjMat := makeCompleteNetwork(numNodes)
+	net := NewDiseasedNetwork(&adjMat, []Disease{disease}, nil, []Observer{}, 9)
+	for step := 0; step < numSteps; step++ {
+		net.Step()
+	}
+	return net, disease
+}
+
+func TestSIS(t *testing.T) {
+	_, disease := runModel(t, SISModel(NewFixedDuration(2)), .05, 50, 100)
+	if disease.NumInState(StateR) != 0 || disease.NumInState(StateE) != 0 {
+		t.Errorf("Expected SIS to only use S and I, found %d in E and %d in R",
+			disease.NumInState(StateE), disease.NumInState(StateR))
+	}
+	reinfected := make(map[int]bool)
+	reinfections := 0
+	for _, c := range disease.InfectionTree().Cases() {
+		if reinfected[c.Node] {
+			reinfections++
+		}
+		reinfected[c.Node] = true
+	}
+	if reinfections == 0 {
+		t.Error("Expected nodes to be infected more than once")
+	}
+}
+
+func TestSIR(t *testing.T) {
+	// infected nodes skip E and start infecting right away
+	_, disease := runModel(t, SIRModel(NewFixedDuration(1)), 1, 20, 1)
+	if disease.NumInState(StateI) != 20 || disease.NumInState(StateE) != 0 {
+		t.Errorf("Expected every node to be infected after 1 step, found %d infected and %d exposed",
+			disease.NumInState(StateI), disease.NumInState(StateE))
+	}
+}
+
+func TestSEIRS(t *testing.T) {
+	_, disease := runModel(t, SEIRSModel(NewFixedDuration(1), NewFixedDuration(1), NewFixedDuration(1)), 1, 20, 4)
+	// step 1 exposes everyone, step 2 makes them infectious and the seed recovers,
+	// step 3 recovers everyone and the seed becomes susceptible and step 4 makes everyone susceptible
+	if disease.NumInState(StateS) != 20 {
+		t.Errorf("Expected every node to lose its immunity, found %d susceptible", disease.NumInState(StateS))
+	}
+}
+
+func TestSEIRD(t *testing.T) {
+	fatality := .3
+	numNodes := 400
+	net, disease := runModel(t, SEIRDModel(NewFixedDuration(1), NewFixedDuration(1), fatality), 1, numNodes, 5)
+	dead := disease.NumInState(StateD)
+	if disease.NumInState(StateR)+dead != numNodes {
+		t.Fatalf("Expected every node to recover or die, found %d recovered and %d dead",
+			disease.NumInState(StateR), dead)
+	}
+	if math.Abs(float64(dead)/float64(numNodes)-fatality) > .07 {
+		t.Errorf("Expected about %f of the nodes to die, found %d of %d", fatality, dead, numNodes)
+	}
+	for _, node := range disease.NodesInState(StateD) {
+		if net.Degree(int(node)) != 0 {
+			t.Errorf("Expected dead node %d to have no edges, found %d", node, net.Degree(int(node)))
+		}
+	}
+	survivors := numNodes - dead
+	for _, node := range disease.NodesInState(StateR) {
+		if net.Degree(int(node)) != survivors-1 {
+			t.Errorf("Expected survivor %d to be connected to the other %d survivors, found %d",
+				node, survivors-1, net.Degree(int(node)))
+			break
+		}
+	}
+}
+
+// TestCustomModel uses a model where infected nodes either show symptoms or don't
+// and only the ones without symptoms keep spreading the disease
+func TestCustomModel(t *testing.T) {
+	const (
+		susceptible = iota
+		infected
+		asymptomatic
+		isolated
+		recovered
+	)
+	model := CompartmentalModel{
+		Compartments: []Compartment{
+			susceptible: {Name: "S", Susceptible: true},
+			infected: {Name: "I", Infected: true, Infectious: true, Duration: NewFixedDuration(1),
+				Next: []Outcome{{Compartment: asymptomatic, Probability: .5}, {Compartment: isolated, Probability: .5}}},
+			asymptomatic: {Name: "A", Infected: true, Infectious: true, Duration: NewFixedDuration(3),
+				Next: moveTo(recovered)},
+			isolated:  {Name: "Q", Infected: true, Duration: NewFixedDuration(3), Next: moveTo(recovered)},
+			recovered: {Name: "R"},
+		},
+		Exposure: infected,
+		Seed:     infected,
+	}
+	_, disease := runModel(t, model, .1, 100, 50)
+	if disease.NumInState(recovered) < 2 || disease.NumInfected() != 0 {
+		t.Errorf("Expected the disease to spread and die out, found %d recovered and %d infected",
+			disease.NumInState(recovered), disease.NumInfected())
+	}
+	for _, c := range disease.InfectionTree().Cases() {
+		if !c.Resolved {
+			t.Errorf("Expected every case to resolve, found %+v", c)
+		}
+	}
+	if disease.Compartments()[isolated].Name != "Q" {
+		t.Errorf("Expected compartment %d to be Q, found %s", isolated, disease.Compartments()[isolated].Name)
+	}
+}
+
+func TestInvalidModels(t *testing.T) {
+	models := map[string]CompartmentalModel{
+		"empty":          {},
+		"bad exposure":   {Compartments: standardCompartments(), Exposure: 9},
+		"bad immune":     {Compartments: standardCompartments(), Immune: 5},
+		"no outcomes":    {Compartments: []Compartment{{Duration: NewFixedDuration(1)}}},
+		"no duration":    {Compartments: []Compartment{{Next: moveTo(0)}}},
+		"bad outcome":    {Compartments: []Compartment{{Duration: NewFixedDuration(1), Next: moveTo(3)}}},
+		"bad fatality":   SEIRDModel(NewFixedDuration(1), NewFixedDuration(1), 1.5),
+		"bad probablity": {Compartments: []Compartment{{Duration: NewFixedDuration(1), Next: []Outcome{{0, .5}}}}},
+	}
+	for name, model := range models {
+		if _, err := NewCompartmentalDisease(model, .5, NewInfectN(1)); err == nil {
+			t.Errorf("Expected an error for the model with %s", name)
+		}
+	}
+}
+
+// TestGoodDiseaseSpreads makes sure the good disease infects nodes directly instead of exposing them
+func TestGoodDiseaseSpreads(t *testing.T) {
+	adjMat := makeCompleteNetwork(20)
+	disease := NewGoodDisease(2, 2, 1, NewInfectN(1))
+	net := NewDiseasedNetwork(&adjMat, []Disease{disease}, nil, []Observer{}, 1)
+	net.Step()
+	if disease.NumInState(StateI) != 20 {
+		t.Errorf("Expected every node to be infected after 1 step, found %d", disease.NumInState(StateI))
+	}
+}
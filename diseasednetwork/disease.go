@@ -1,27 +1,63 @@
 package diseasednetwork
 
-// todo: add a rating function to basicDisease
+import "math/rand"
 
-// represent disease states
+// represent disease states. These are the compartments of the built in models.
 const (
 	StateS = iota
 	StateE = iota
 	StateI = iota
 	StateR = iota
+	StateD = iota
 )
 
-// NewBasicDisease creates a new Disease with the given values
+// NewBasicDisease creates a new SEIR Disease where every node is exposed for exactly timeToI steps
+// and infectious for exactly timeToR steps
 func NewBasicDisease(timeToI, timeToR int16, infectionProbability float32,
 	infectionStrategy InitialInfectionStrategy) Disease {
-	return &basicDisease{
-		timeToI:              timeToI,
-		timeToR:              timeToR,
+	return NewBasicDiseaseWithDurations(NewFixedDuration(timeToI), NewFixedDuration(timeToR),
+		infectionProbability, infectionStrategy)
+}
+
+// NewBasicDiseaseWithDurations creates a new SEIR Disease where each node draws how long it is
+// exposed from timeToI and how long it is infectious from timeToR
+func NewBasicDiseaseWithDurations(timeToI, timeToR DurationDistribution, infectionProbability float32,
+	infectionStrategy InitialInfectionStrategy) Disease {
+	return mustCompartmentalDisease(SEIRModel(timeToI, timeToR), infectionProbability, infectionStrategy)
+}
+
+// NewGoodDisease makes a positive "disease". This could be information dispersion or some
+// other sort of positive interaction. It follows the SIRS pattern.
+func NewGoodDisease(timeToR, timeToS int16, infProb float32, infStrat InitialInfectionStrategy) Disease {
+	model := SIRSModel(NewFixedDuration(timeToR), NewFixedDuration(timeToS))
+	return mustCompartmentalDisease(model, infProb, infStrat)
+}
+
+// NewCompartmentalDisease creates a Disease that follows model. The built in models are made by
+// SISModel, SIRModel, SIRSModel, SEIRModel, SEIRSModel and SEIRDModel, but any set of
+// compartments and transitions can be used. An error is returned if the model is inconsistent.
+func NewCompartmentalDisease(model CompartmentalModel, infectionProbability float32,
+	infectionStrategy InitialInfectionStrategy) (Disease, error) {
+	compiled, err := compileModel(model)
+	if err != nil {
+		return nil, err
+	}
+	return &compartmentalDisease{
+		compiled:             compiled,
 		infectionProbability: infectionProbability,
-		nodeState:            make(map[int]uint8),
-		timeInState:          make(map[int]int16),
 		infStrat:             infectionStrategy,
 		numNodes:             -1,
+	}, nil
+}
+
+// mustCompartmentalDisease is NewCompartmentalDisease for models that are known to be valid
+func mustCompartmentalDisease(model CompartmentalModel, infectionProbability float32,
+	infectionStrategy InitialInfectionStrategy) Disease {
+	disease, err := NewCompartmentalDisease(model, infectionProbability, infectionStrategy)
+	if err != nil {
+		panic(err)
 	}
+	return disease
 }
 
 // Disease represents an infection that spreads across a network
@@ -36,61 +72,89 @@ type Disease interface {
 	SetNumNodes(n int)
 	NumNodes() int
 	FindNodesInState(state int) map[int]Void
+	// NumInState returns the number of nodes in state in O(1) time
+	NumInState(state int) int
+	// NodesInState returns the nodes in state in no particular order without scanning the network.
+	// The slice must not be modified and is only valid until the next call to SetState.
+	NodesInState(state int) []int32
+	// NumInfected returns the number of nodes in the infected compartments
+	NumInfected() int
+	// Compartments describes the states nodes can be in. The slice must not be modified.
+	Compartments() []Compartment
 	MakeCopy() Disease
-	updateStates()
+	// updateStates moves the nodes that have been in their states long enough on to the next
+	// state. Durations are drawn from rand.
+	updateStates(rand *rand.Rand)
+	// advanceTime adds one step to the time in state of every node
+	advanceTime()
 	Rate() float64
+	// R0 estimates the basic reproduction number from the infection tree
 	R0() float64
-	ReportInfections(node int, numInfections uint)
-	endStep()
+	// InfectionTree records who infected whom since the disease started spreading
+	InfectionTree() *InfectionTree
+	// recordInfection should be called after infected's state changes because of infector
+	recordInfection(infector, infected int)
+	// beginStep should be called at the start of the Step method in DiseasedNetwork
+	beginStep()
+	// model gives the compartments of the disease and their properties
+	model() *compiledModel
+	// observe reports the state changes and infections of the disease to events as the disease
+	// at diseaseIndex
+	observe(events *observation, diseaseIndex int)
 }
 
-// basicDisease is a simple disease with constant values
-type basicDisease struct {
-	timeToI              int16
-	timeToR              int16
+// compartmentalDisease moves nodes through the compartments of a CompartmentalModel
+type compartmentalDisease struct {
+	compiled             *compiledModel
 	infectionProbability float32
-	nodeState            map[int]uint8
-	timeInState          map[int]int16
+	states               stateTracker
 	infStrat             InitialInfectionStrategy
 	numNodes             int
-	numNodesInfectedBy   []uint
+	tree                 *InfectionTree
+	// events is nil unless the disease is observed
+	events       *observation
+	diseaseIndex int
 }
 
 // InfectionProbability returns the probability that in one time step a node will infect
 // its neighbor
-func (d *basicDisease) InfectionProbability() float32 {
+func (d *compartmentalDisease) InfectionProbability() float32 {
 	return d.infectionProbability
 }
 
-func (d *basicDisease) State(node int) uint8 {
-	return d.nodeState[node]
+func (d *compartmentalDisease) State(node int) uint8 {
+	return d.states.stateOf(node)
 }
 
-func (d *basicDisease) SetState(node int, state uint8) {
-	d.nodeState[node] = state
-	d.timeInState[node] = 0
+func (d *compartmentalDisease) SetState(node int, state uint8) {
+	old := d.states.stateOf(node)
+	d.states.setState(node, state)
+	d.tree.stateChanged(node, old, state)
+	if d.events != nil && old != state {
+		d.events.stateChanged(d.diseaseIndex, node, old, state)
+	}
 }
 
-func (d *basicDisease) ResetTimeInState(node int) {
-	d.timeInState[node] = 0
+func (d *compartmentalDisease) ResetTimeInState(node int) {
+	d.states.resetTimeInState(node)
 }
 
-func (d *basicDisease) IncTimeInState(node int) {
-	d.timeInState[node]++
+func (d *compartmentalDisease) IncTimeInState(node int) {
+	d.states.incTimeInState(node)
 }
 
-func (d *basicDisease) TimeInState(node int) int16 {
-	return d.timeInState[node]
+func (d *compartmentalDisease) TimeInState(node int) int16 {
+	return d.states.timeInState(node)
 }
 
-func (d *basicDisease) InitialInfection() InitialInfectionStrategy {
+func (d *compartmentalDisease) InitialInfection() InitialInfectionStrategy {
 	return d.infStrat
 }
 
 // NumNodes returns the number of nodes that the disease infects. This is used for the
 // initial infection strategy. numNodes starts at -1 and if it isn't set to a nonnegative value
 // this function will panic when called.
-func (d *basicDisease) NumNodes() int {
+func (d *compartmentalDisease) NumNodes() int {
 	if d.numNodes < 0 {
 		panic("Disease has negative number of nodes!")
 	}
@@ -99,93 +163,105 @@ func (d *basicDisease) NumNodes() int {
 
 // SetNumNodes MUST be called before actually using the disease. Unfortunately, because of the
 // way the structs relate to each other, it isn't possible to specify the number of nodes when
-// the disease is created.
-func (d *basicDisease) SetNumNodes(n int) {
+// the disease is created. Every node starts out in compartment 0.
+func (d *compartmentalDisease) SetNumNodes(n int) {
 	d.numNodes = n
-	d.numNodesInfectedBy = make([]uint, d.numNodes)
+	d.states = newStateTracker(n, len(d.compiled.Compartments))
+	d.tree = newInfectionTree(n, d.compiled.infected)
 }
 
 // FindNodesInState finds all the nodes in the network with the given state
-func (d *basicDisease) FindNodesInState(state int) map[int]Void {
-	s := uint8(state)
-	nodes := make(map[int]Void)
-	for node, st := range d.nodeState {
-		if s == st {
-			nodes[node] = Void{}
+func (d *compartmentalDisease) FindNodesInState(state int) map[int]Void {
+	return d.states.findNodesInState(state)
+}
+
+func (d *compartmentalDisease) NumInState(state int) int {
+	return d.states.count(state)
+}
+
+func (d *compartmentalDisease) NodesInState(state int) []int32 {
+	return d.states.nodesIn(state)
+}
+
+func (d *compartmentalDisease) NumInfected() int {
+	total := 0
+	for state, infected := range d.compiled.infected {
+		if infected {
+			total += d.states.count(state)
 		}
 	}
-	return nodes
+	return total
+}
+
+func (d *compartmentalDisease) Compartments() []Compartment {
+	return d.compiled.Compartments
 }
 
-// updateStates updates the states and the time in state for all the nodes
-func (d *basicDisease) updateStates() {
-	exposedNodes := d.FindNodesInState(StateE)
-	infectedNodes := d.FindNodesInState(StateI)
-	for node := range exposedNodes {
-		if d.TimeInState(node) == d.timeToI {
-			d.SetState(node, StateI)
+// updateStates moves every node whose time is up to its next compartment.
+// All the nodes are copied out of the tracker first so that a node only moves once per step.
+func (d *compartmentalDisease) updateStates(rand *rand.Rand) {
+	compartments := d.compiled.Compartments
+	waiting := make([][]int32, len(compartments))
+	for state, c := range compartments {
+		if c.Duration != nil {
+			waiting[state] = append([]int32(nil), d.NodesInState(state)...)
 		}
 	}
-	for node := range infectedNodes {
-		if d.TimeInState(node) == d.timeToR {
-			d.SetState(node, StateR)
+	for state, nodes := range waiting {
+		for _, node := range nodes {
+			if d.states.timeIsUp(int(node), compartments[state].Duration, rand) {
+				d.SetState(int(node), d.compiled.nextCompartment(uint8(state), rand))
+			}
 		}
 	}
 }
 
-func (d *basicDisease) Rate() float64 {
-	susceptibleNodes := len(d.FindNodesInState(StateS))
-	// exposedNodes := len(network.FindNodesInState(diseasednetwork.StateE))
-	// infectedNodes := len(network.FindNodesInState(diseasednetwork.StateI))
-	// removedNodes := len(network.FindNodesInState(diseasednetwork.StateR))
-	// fmt.Printf("%d S, %d E, %d I, %d R\n", susceptibleNodes, infectedNodes, exposedNodes, removedNodes)
-	totalNodes := d.NumNodes()
-	return float64(susceptibleNodes) / float64(totalNodes)
+func (d *compartmentalDisease) advanceTime() {
+	d.states.advanceTime()
+}
+
+// Rate is the proportion of nodes still in compartment 0
+func (d *compartmentalDisease) Rate() float64 {
+	return float64(d.NumInState(0)) / float64(d.NumNodes())
 }
 
 // R0 calculates the R0 of the disease
-func (d *basicDisease) R0() float64 {
-	numSpreaders := uint(0)
-	numInfectedBySpreaders := uint(0)
-	for _, numInfected := range d.numNodesInfectedBy {
-		if numInfected > 0 {
-			numSpreaders++
-			numInfectedBySpreaders += numInfected
-		}
-	}
-	// If no new nodes actually caught the disease, return 0 instead of NaN
-	if numSpreaders == 0 {
-		return 0
+func (d *compartmentalDisease) R0() float64 {
+	return d.tree.R0()
+}
+
+func (d *compartmentalDisease) InfectionTree() *InfectionTree {
+	return d.tree
+}
+
+func (d *compartmentalDisease) recordInfection(infector, infected int) {
+	d.tree.recordInfection(infector, infected)
+	if d.events != nil {
+		d.events.infected(d.diseaseIndex, infector, infected)
 	}
-	return float64(numInfectedBySpreaders) / float64(numSpreaders)
 }
 
-// endStep should be called at the end of the Step method in DiseasedNetwork
-// so that the disease can clean up its counting mechanisms.
-func (d *basicDisease) endStep() {
-	d.numNodesInfectedBy = make([]uint, d.numNodes)
+func (d *compartmentalDisease) beginStep() {
+	d.tree.beginStep()
 }
 
-func (d *basicDisease) ReportInfections(node int, numInfections uint) {
-	d.numNodesInfectedBy[node] += numInfections
+func (d *compartmentalDisease) model() *compiledModel {
+	return d.compiled
 }
 
-func (d *basicDisease) MakeCopy() Disease {
-	nodeState := make(map[int]uint8)
-	for node, state := range d.nodeState {
-		nodeState[node] = state
-	}
-	timeInState := make(map[int]int16)
-	for node, time := range d.timeInState {
-		timeInState[node] = time
-	}
-	return &basicDisease{
-		timeToI:              d.timeToI,
-		timeToR:              d.timeToR,
+func (d *compartmentalDisease) observe(events *observation, diseaseIndex int) {
+	d.events = events
+	d.diseaseIndex = diseaseIndex
+}
+
+// MakeCopy copies the disease without its observers
+func (d *compartmentalDisease) MakeCopy() Disease {
+	return &compartmentalDisease{
+		compiled:             d.compiled,
 		infectionProbability: d.infectionProbability,
-		nodeState:            nodeState,
-		timeInState:          timeInState,
+		states:               d.states.makeCopy(),
 		infStrat:             d.infStrat,
 		numNodes:             d.numNodes,
+		tree:                 copyTree(d.tree),
 	}
 }
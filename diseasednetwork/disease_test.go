@@ -10,7 +10,7 @@ func TestStep(t *testing.T) {
 	numNodes := 500
 	adjMat := makeCompleteNetwork(numNodes)
 	dis := NewBasicDisease(1, 1, 1.0, InfectN{n: 1})
-	net := NewDiseasedNetwork([]Disease{dis}, adjMat)
+	net := New(&adjMat, []Disease{dis})
 
 	numInfected := len(dis.FindNodesInState(StateI))
 	if numInfected != 1 {
@@ -3,6 +3,8 @@ package diseasednetwork
 import (
 	"math/rand"
 	"time"
+
+	"github.com/GaudiestTooth17/infection-resistant-network/dynamicnet"
 )
 
 // Void is an empty struct used for more space efficient maps/sets
@@ -10,11 +12,35 @@ type Void struct{}
 
 // DiseasedNetwork represents a dynamic network with a disease that tries to adapt to slow
 // the spread of the disease. The bad disease should be put in slot 0.
+// The agents adapt according to behavior. If behavior is nil the network never changes.
 type DiseasedNetwork struct {
-	diseases   []Disease
-	adjMat     Network
-	stepNum    uint
-	PlotMakers []PlotMaker
+	diseases []Disease
+	adjMat   Network
+	behavior dynamicnet.AgentBehavior
+	engine   Engine
+	// transmission turns the weight of an edge into the chance of infecting across it
+	transmission TransmissionFunc
+	// maxMultiplier is the most the node attributes can multiply the chance of infection by
+	maxMultiplier float32
+	// interactions change how the diseases affect each other and maxInteraction is the most they
+	// can multiply the chance of infection by for each disease
+	interactions   []compiledInteraction
+	maxInteraction []float32
+	// nodes infected with the disease at awareDisease follow awareBehavior if it isn't nil
+	awareDisease  int
+	awareBehavior dynamicnet.AgentBehavior
+	// importations bring in infections from outside the network
+	importations []Importation
+	// interventions vaccinate nodes and dosesGiven is how many doses each one has given out.
+	// vaccinated marks the nodes that have had a dose and immunized the ones it worked on.
+	interventions []Intervention
+	dosesGiven    []int
+	vaccinated    []bool
+	immunized     []bool
+	rand          *rand.Rand
+	stepNum       uint
+	// events is shared with the diseases so they can tell the observers what happens to them
+	events *observation
 }
 
 // NumNodes returns the number of nodes in a network
@@ -22,108 +48,222 @@ func (n *DiseasedNetwork) NumNodes() int {
 	return n.adjMat.NumNodes()
 }
 
-// NewDiseasedNetwork creates a new instance of DiseasedNetwork
-func NewDiseasedNetwork(underlyingNet *Network, diseases []Disease, plotMakers []PlotMaker) DiseasedNetwork {
+// Degree returns the number of neighbors node currently has
+func (n *DiseasedNetwork) Degree(node int) int {
+	return n.adjMat.Degree(node)
+}
+
+// Neighbors returns the current neighbors of node in ascending order. See Network.Neighbors.
+func (n *DiseasedNetwork) Neighbors(node int) []int32 {
+	return n.adjMat.Neighbors(node)
+}
+
+// NeighborWeights returns the weights of the edges to the nodes returned by Neighbors
+func (n *DiseasedNetwork) NeighborWeights(node int) []uint8 {
+	return n.adjMat.NeighborWeights(node)
+}
+
+// NumDiseases returns the number of diseases spreading on the network
+func (n *DiseasedNetwork) NumDiseases() int {
+	return len(n.diseases)
+}
+
+// Compartments describes the states nodes can be in for the specified disease
+func (n *DiseasedNetwork) Compartments(diseaseIndex int) []Compartment {
+	return n.diseases[diseaseIndex].Compartments()
+}
+
+// addEdge connects node1 and node2 and tells the observers about it
+func (n *DiseasedNetwork) addEdge(node1, node2 int, weight uint8) {
+	n.adjMat.AddEdge(node1, node2, weight)
+	n.events.edgeAdded(node1, node2, weight)
+}
+
+// removeEdge disconnects node1 and node2 and tells the observers about it
+func (n *DiseasedNetwork) removeEdge(node1, node2 int) {
+	n.adjMat.removeEdge(node1, node2)
+	n.events.edgeRemoved(node1, node2)
+}
+
+// New creates a DiseasedNetwork on a copy of underlyingNet and chooses the initial infections of
+// each disease. Everything else is configured with options, which are applied in order before the
+// initial infections. All of the randomness in the simulation, including the initial infection,
+// comes from the seed set by WithSeed, so two networks made with the same arguments will always
+// behave identically.
+func New(underlyingNet *Network, diseases []Disease, options ...Option) DiseasedNetwork {
 	net := DiseasedNetwork{
-		diseases:   diseases,
-		adjMat:     underlyingNet.MakeCopy(),
-		stepNum:    0,
-		PlotMakers: plotMakers,
+		diseases:      diseases,
+		adjMat:        underlyingNet.MakeCopy(),
+		transmission:  IndependentContacts,
+		maxMultiplier: maxMultiplier(underlyingNet.attributes),
+		rand:          rand.New(rand.NewSource(0)),
+		stepNum:       0,
+		events:        &observation{},
+	}
+	for _, option := range options {
+		option(&net)
 	}
 
 	for _, disease := range net.diseases {
 		disease.SetNumNodes(net.adjMat.NumNodes())
-		for node := 0; node < disease.NumNodes(); node++ {
-			disease.SetState(node, StateS)
-		}
 		infectionStrategy := disease.InitialInfection()
-		infectionStrategy.apply(disease)
+		infectionStrategy.apply(&net, disease, net.rand)
 	}
 
+	if len(net.events.observers) > 0 {
+		for i, disease := range net.diseases {
+			disease.observe(net.events, i)
+		}
+		for _, observer := range net.events.observers {
+			observer.RunStarted(&net)
+		}
+	}
 	return net
 }
 
+// NewDiseasedNetwork creates a new instance of DiseasedNetwork. It is the same as New with
+// WithBehavior, WithObservers and WithSeed.
+// behavior may be nil if the network should stay static.
+func NewDiseasedNetwork(underlyingNet *Network, diseases []Disease,
+	behavior dynamicnet.AgentBehavior, observers []Observer, seed int64) DiseasedNetwork {
+	return New(underlyingNet, diseases,
+		WithBehavior(behavior), WithObservers(observers...), WithSeed(seed))
+}
+
+// SetEngine chooses how the diseases move forward in each step. Networks start out using the
+// DiscreteEngine. The network itself is always rewired once at the start of each step.
+func (n *DiseasedNetwork) SetEngine(engine Engine) {
+	n.engine = engine
+}
+
 // Step through one time step
 func (n *DiseasedNetwork) Step() (time.Duration, float64) {
 	stepStart := time.Now()
-	n.spreadInfection()
-	n.updateStates()
+	n.events.step = n.stepNum + 1
 	for _, dis := range n.diseases {
-		for node := 0; node < n.NumNodes(); node++ {
-			dis.IncTimeInState(node)
-		}
+		dis.beginStep()
 	}
-	// let the plotMakers measure the network
-	for _, plotMaker := range n.PlotMakers {
-		plotMaker.feedInformation(n)
+	n.infectLater()
+	n.importInfections()
+	n.intervene()
+	n.rewire()
+	if n.engine == GillespieEngine {
+		n.runContinuousTime()
+	} else {
+		n.spreadInfection()
+		n.updateStates()
 	}
-	// let the diseases reset their counting
-	r0 := n.diseases[0].R0()
+	n.removeDeadNodes()
 	for _, dis := range n.diseases {
-		dis.endStep()
+		dis.advanceTime()
 	}
+	r0 := n.diseases[0].R0()
 	n.stepNum++
+	for _, observer := range n.events.observers {
+		observer.StepEnded(n, n.stepNum)
+	}
 	return time.Now().Sub(stepStart), r0
 }
 
+// infectLater lets the infection strategies that keep infecting nodes after the start do so
+func (n *DiseasedNetwork) infectLater() {
+	for _, disease := range n.diseases {
+		if strategy, ok := disease.InitialInfection().(laterInfectionStrategy); ok {
+			strategy.applyLater(n, disease, n.stepNum+1, n.rand)
+		}
+	}
+}
+
 // spreadInfection of all the diseases by finding the infectious nodes, the nodes they could infect,
-// and then randomly determining if each one will get infected
+// and then randomly determining if each one will get infected. The chance depends on the weight
+// of the edge between them (see SetTransmission), on their attributes (see SetNodeAttributes)
+// and on the other diseases (see SetInteractions).
+// The order nodes are visited in only depends on what has happened so far in the simulation,
+// so the random numbers are always used the same way.
+// A node that is at risk from several infectious neighbors can only be infected by one of them.
+// The infection is credited to the first one to succeed.
 func (n *DiseasedNetwork) spreadInfection() {
 	for i, disease := range n.diseases {
-		infectiousNodes := disease.FindNodesInState(StateI)
-		atRiskGroups := make(map[int]map[int]uint8, len(infectiousNodes))
-		for node := range infectiousNodes {
-			atRiskGroups[node] = n.findNeighbors(node, StateS, i)
+		model := disease.model()
+		// the at risk groups have to be found before anyone is infected so that
+		// nodes infected this step can't be counted as at risk.
+		// The infectious nodes are copied because newly infected nodes may join their compartments.
+		infectiousNodes := make([]int32, 0)
+		for _, state := range model.infectiousStates {
+			infectiousNodes = append(infectiousNodes, disease.NodesInState(int(state))...)
+		}
+		atRiskGroups := make([][]int32, len(infectiousNodes))
+		for j, node := range infectiousNodes {
+			atRiskGroups[j] = n.findNeighbors(int(node), model.susceptible, i)
 		}
 
-		for infectiousNode, group := range atRiskGroups {
-			nodesInfected := uint(0)
-			for atRiskNode := range group {
-				if rand.Float32() < disease.InfectionProbability() {
-					disease.SetState(atRiskNode, StateE)
-					nodesInfected++
+		for j, infectiousNode := range infectiousNodes {
+			for _, atRiskNode := range atRiskGroups[j] {
+				if !model.susceptible[disease.State(int(atRiskNode))] {
+					continue
+				}
+				if n.rand.Float32() < n.edgeProbability(i, int(infectiousNode), int(atRiskNode)) {
+					disease.SetState(int(atRiskNode), model.Exposure)
+					disease.recordInfection(int(infectiousNode), int(atRiskNode))
 				}
 			}
-			disease.ReportInfections(infectiousNode, nodesInfected)
 		}
 	}
 }
 
-// findNeighbors finds all the neighbors of node with the indicated state.
-// Use a negative value to find all neighbors.
-func (n *DiseasedNetwork) findNeighbors(node int, state int, diseaseIndex int) map[int]uint8 {
-	neighbors := n.adjMat.NeighborsOf(node)
-	if state < 0 {
+// findNeighbors finds all the neighbors of node in ascending order whose state in the
+// specified disease is marked in states. Use nil to find all neighbors.
+func (n *DiseasedNetwork) findNeighbors(node int, states []bool, diseaseIndex int) []int32 {
+	neighbors := n.adjMat.Neighbors(node)
+	if states == nil {
 		return neighbors
 	}
 
-	neighborsInState := make(map[int]uint8)
-	for neighbor, edgeWeight := range neighbors {
-		if n.diseases[diseaseIndex].State(neighbor) == uint8(state) {
-			neighborsInState[neighbor] = edgeWeight
+	neighborsInState := make([]int32, 0)
+	for _, neighbor := range neighbors {
+		if states[n.diseases[diseaseIndex].State(int(neighbor))] {
+			neighborsInState = append(neighborsInState, neighbor)
 		}
 	}
 	return neighborsInState
 }
 
-// updateStates changes the state of exposed and infected nodes if they have been I/E for long enough.
-// This happens for each disease in diseases.
+// updateStates moves nodes on to their next compartments if they have been in their current ones
+// for long enough. This happens for each disease in diseases.
 func (n *DiseasedNetwork) updateStates() {
 	for _, disease := range n.diseases {
-		disease.updateStates()
+		disease.updateStates(n.rand)
 	}
 }
 
-// FindNodesInState returns a set of all the nodes in a certain state in the specified disease
-func (n *DiseasedNetwork) FindNodesInState(state int, diseaseIndex int) map[int]Void {
-	s := uint8(state)
-	nodes := make(map[int]Void)
-	for node := 0; node < n.NumNodes(); node++ {
-		if n.diseases[diseaseIndex].State(node) == s {
-			nodes[node] = Void{}
+// removeDeadNodes cuts all the edges of nodes that are dead in any of the diseases
+func (n *DiseasedNetwork) removeDeadNodes() {
+	for _, disease := range n.diseases {
+		for _, state := range disease.model().deadStates {
+			for _, node := range disease.NodesInState(int(state)) {
+				neighbors := n.adjMat.Neighbors(int(node))
+				for len(neighbors) > 0 {
+					n.removeEdge(int(node), int(neighbors[len(neighbors)-1]))
+					neighbors = n.adjMat.Neighbors(int(node))
+				}
+			}
 		}
 	}
-	return nodes
+}
+
+// FindNodesInState returns a set of all the nodes in a certain state in the specified disease
+func (n *DiseasedNetwork) FindNodesInState(state int, diseaseIndex int) map[int]Void {
+	return n.diseases[diseaseIndex].FindNodesInState(state)
+}
+
+// NumInState returns the number of nodes in a certain state in the specified disease
+func (n *DiseasedNetwork) NumInState(state int, diseaseIndex int) int {
+	return n.diseases[diseaseIndex].NumInState(state)
+}
+
+// NumInfected returns the number of nodes in the infected compartments of the specified disease
+func (n *DiseasedNetwork) NumInfected(diseaseIndex int) int {
+	return n.diseases[diseaseIndex].NumInfected()
 }
 
 // GetNodeStates returns a slice where the ith index contains the state of the ith node
@@ -136,7 +276,12 @@ func (n *DiseasedNetwork) GetNodeStates(diseaseNumber int) []uint8 {
 	return nodeStates
 }
 
-// R0 gives the R0 of the specified disease.
+// R0 gives the R0 of the specified disease. See InfectionTree.R0 for how it is estimated.
 func (n *DiseasedNetwork) R0(diseaseNum int) float64 {
 	return n.diseases[diseaseNum].R0()
 }
+
+// InfectionTree gives the record of who infected whom in the specified disease
+func (n *DiseasedNetwork) InfectionTree(diseaseNum int) *InfectionTree {
+	return n.diseases[diseaseNum].InfectionTree()
+}
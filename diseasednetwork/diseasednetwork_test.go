@@ -0,0 +1,133 @@
+package diseasednetwork
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/GaudiestTooth17/infection-resistant-network/dynamicnet"
+)
+
+// runStates steps net numSteps times and returns the states of the bad disease after each step
+func runStates(net *DiseasedNetwork, numSteps int) [][]uint8 {
+	states := make([][]uint8, numSteps)
+	for step := range states {
+		net.Step()
+		states[step] = net.GetNodeStates(0)
+	}
+	return states
+}
+
+// TestNewMatchesNewDiseasedNetwork makes sure both constructors use the seed the same way
+func TestNewMatchesNewDiseasedNetwork(t *testing.T) {
+	adjMat := makeCircularNetwork(200)
+	behavior := dynamicnet.NewSimpleBehavior(1, 4, .3, .2)
+	old := NewDiseasedNetwork(&adjMat, []Disease{NewBasicDisease(1, 3, .4, NewInfectN(5))},
+		behavior, []Observer{}, 12)
+	net := New(&adjMat, []Disease{NewBasicDisease(1, 3, .4, NewInfectN(5))},
+		WithSeed(12), WithBehavior(behavior))
+	if !reflect.DeepEqual(runStates(&old, 20), runStates(&net, 20)) {
+		t.Error("Expected the same simulation from both constructors")
+	}
+
+	unseeded := New(&adjMat, []Disease{NewBasicDisease(1, 3, .4, NewInfectN(5))})
+	zeroSeed := New(&adjMat, []Disease{NewBasicDisease(1, 3, .4, NewInfectN(5))}, WithSeed(0))
+	if !reflect.DeepEqual(runStates(&unseeded, 20), runStates(&zeroSeed, 20)) {
+		t.Error("Expected networks without a seed to use a seed of 0")
+	}
+}
+
+// TestOptionsApplyBeforeInfection makes sure the initial infections can use the options
+func TestOptionsApplyBeforeInfection(t *testing.T) {
+	adjMat := makeCircularNetwork(50)
+	attributes := DefaultAttributes(50)
+	attributes[7].Group = 3
+	disease := NewBasicDisease(1, 3, 0, NewInfectGroup(3, 10))
+	net := New(&adjMat, []Disease{disease}, WithNodeAttributes(attributes),
+		WithInterventions(NewIntervention(NewDegreeTargeting(), 5, 1)))
+	if disease.NumInState(StateI) != 1 || disease.State(7) != StateI {
+		t.Errorf("Expected only node 7 to be infected, found %v", disease.FindNodesInState(StateI))
+	}
+	net.Step()
+	if net.NumImmunized() != 5 {
+		t.Errorf("Expected 5 nodes to be immunized, found %d", net.NumImmunized())
+	}
+}
+
+// TestStepR0 checks the R0 reported by Step on a star where the center infects every leaf
+func TestStepR0(t *testing.T) {
+	adjMat := NewNetwork(11)
+	for node := 1; node < 11; node++ {
+		adjMat.AddEdge(0, node, 1)
+	}
+	disease, err := NewCompartmentalDisease(SIRModel(NewFixedDuration(1)), 1, NewInfectNodes(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	net := New(&adjMat, []Disease{disease})
+	expected := []float64{0, 10, 10, 10}
+	for step, r0 := range expected {
+		_, found := net.Step()
+		if found != r0 || net.R0(0) != r0 {
+			t.Errorf("Expected an R0 of %f after step %d, found %f from Step and %f from R0",
+				r0, step+1, found, net.R0(0))
+		}
+	}
+	if disease.NumInState(StateR) != 11 {
+		t.Errorf("Expected every node to have recovered, found %d", disease.NumInState(StateR))
+	}
+}
+
+func TestR0WithoutSpread(t *testing.T) {
+	adjMat := makeCompleteNetwork(30)
+	net := New(&adjMat, []Disease{NewBasicDisease(1, 2, 0, NewInfectN(4))}, WithSeed(3))
+	for step := 0; step < 5; step++ {
+		net.Step()
+	}
+	if net.R0(0) != 0 {
+		t.Errorf("Expected an R0 of 0 when nothing can spread, found %f", net.R0(0))
+	}
+}
+
+// TestGoodDiseaseCycles makes sure the good disease brings nodes back to susceptible
+func TestGoodDiseaseCycles(t *testing.T) {
+	adjMat := makeCompleteNetwork(20)
+	good := NewGoodDisease(2, 3, 1, NewInfectN(1))
+	net := New(&adjMat, []Disease{good})
+	allInfected, backToSusceptible := false, false
+	for step := 0; step < 10; step++ {
+		net.Step()
+		if good.NumInState(StateE) > 0 || good.NumInState(StateD) > 0 {
+			t.Fatalf("The good disease shouldn't use E or D, found %d and %d",
+				good.NumInState(StateE), good.NumInState(StateD))
+		}
+		if good.NumInState(StateI) == 20 {
+			allInfected = true
+		} else if allInfected && good.NumInState(StateS) > 0 {
+			backToSusceptible = true
+		}
+	}
+	if !allInfected || !backToSusceptible {
+		t.Errorf("Expected every node to be infected and then become susceptible again, found %t and %t",
+			allInfected, backToSusceptible)
+	}
+}
+
+// TestGoodDiseaseDoesNotCauseRewiring makes sure agents only react to the bad disease in slot 0
+func TestGoodDiseaseDoesNotCauseRewiring(t *testing.T) {
+	adjMat := makeCompleteNetwork(20)
+	bad := NewBasicDisease(1, 2, 0, NewInfectN(0))
+	good := NewGoodDisease(5, 5, 1, NewInfectN(1))
+	net := New(&adjMat, []Disease{bad, good},
+		WithBehavior(dynamicnet.NewSimpleBehavior(0, 19, 1, 0)))
+	for step := 0; step < 4; step++ {
+		net.Step()
+	}
+	if good.NumInState(StateI) != 20 {
+		t.Errorf("Expected the good disease to reach every node, found %d", good.NumInState(StateI))
+	}
+	for node := 0; node < 20; node++ {
+		if net.Degree(node) != 19 {
+			t.Errorf("Expected node %d to keep all 19 of its neighbors, found %d", node, net.Degree(node))
+		}
+	}
+}
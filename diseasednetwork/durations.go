@@ -0,0 +1,235 @@
+package diseasednetwork
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// DurationDistribution is the distribution of the number of steps a node spends in a state.
+// Each time a node enters the state it gets its own duration from the distribution.
+type DurationDistribution interface {
+	// Mean is the average number of steps. The continuous-time engine uses it as the
+	// mean of an exponential distribution.
+	Mean() float64
+	// sample draws a duration. It should get all of its randomness from rand.
+	sample(rand *rand.Rand) int16
+}
+
+// toDuration rounds a sampled value to the nearest number of steps that fits in an int16
+func toDuration(steps float64) int16 {
+	if steps >= math.MaxInt16 {
+		return math.MaxInt16
+	}
+	if steps <= 0 {
+		return 0
+	}
+	return int16(math.Round(steps))
+}
+
+// FixedDuration always lasts the same number of steps. It doesn't use any random numbers.
+type FixedDuration struct {
+	steps int16
+}
+
+// NewFixedDuration returns a FixedDuration that lasts steps steps
+func NewFixedDuration(steps int16) FixedDuration {
+	if steps < 0 {
+		panic("Durations can't be negative!")
+	}
+	return FixedDuration{steps: steps}
+}
+
+// Mean is the number of steps
+func (d FixedDuration) Mean() float64 {
+	return float64(d.steps)
+}
+
+func (d FixedDuration) sample(rand *rand.Rand) int16 {
+	return d.steps
+}
+
+// GeometricDuration gives a node the same chance of leaving its state in every step,
+// so it is the discrete version of the exponential distribution. It can last 0 steps.
+type GeometricDuration struct {
+	mean float64
+}
+
+// NewGeometricDuration returns a GeometricDuration with the given mean number of steps
+func NewGeometricDuration(mean float64) GeometricDuration {
+	if mean < 0 {
+		panic("Durations can't be negative!")
+	}
+	return GeometricDuration{mean: mean}
+}
+
+// Mean is the average number of steps
+func (d GeometricDuration) Mean() float64 {
+	return d.mean
+}
+
+// sample inverts the cumulative distribution. Each step is left with probability 1/(mean+1).
+func (d GeometricDuration) sample(rand *rand.Rand) int16 {
+	if d.mean == 0 {
+		return 0
+	}
+	stayProbability := d.mean / (d.mean + 1)
+	return toDuration(math.Floor(math.Log(1-rand.Float64()) / math.Log(stayProbability)))
+}
+
+// PoissonDuration has a Poisson distributed number of steps
+type PoissonDuration struct {
+	mean float64
+}
+
+// NewPoissonDuration returns a PoissonDuration with the given mean number of steps
+func NewPoissonDuration(mean float64) PoissonDuration {
+	if mean < 0 {
+		panic("Durations can't be negative!")
+	}
+	return PoissonDuration{mean: mean}
+}
+
+// Mean is the average number of steps
+func (d PoissonDuration) Mean() float64 {
+	return d.mean
+}
+
+// sample multiplies uniform numbers together for small means. That takes time proportional
+// to the mean, so larger means use Hörmann's transformed rejection method (PTRS).
+func (d PoissonDuration) sample(rand *rand.Rand) int16 {
+	if d.mean < 10 {
+		limit := math.Exp(-d.mean)
+		k := 0
+		for product := rand.Float64(); product > limit; product *= rand.Float64() {
+			k++
+		}
+		return toDuration(float64(k))
+	}
+
+	logMean := math.Log(d.mean)
+	b := .931 + 2.53*math.Sqrt(d.mean)
+	a := -.059 + .02483*b
+	invAlpha := 1.1239 + 1.1328/(b-3.4)
+	vr := .9277 - 3.6224/(b-2)
+	for {
+		u := rand.Float64() - .5
+		v := rand.Float64()
+		us := .5 - math.Abs(u)
+		k := math.Floor((2*a/us+b)*u + d.mean + .43)
+		if us >= .07 && v <= vr {
+			return toDuration(k)
+		}
+		if k < 0 || (us < .013 && v > us) {
+			continue
+		}
+		logFactorial, _ := math.Lgamma(k + 1)
+		if math.Log(v)+math.Log(invAlpha)-math.Log(a/(us*us)+b) <= -d.mean+k*logMean-logFactorial {
+			return toDuration(k)
+		}
+	}
+}
+
+// GammaDuration has a gamma distributed number of steps, rounded to the nearest step.
+// A shape of 1 is an exponential distribution, and larger shapes are less spread out.
+type GammaDuration struct {
+	shape float64
+	scale float64
+}
+
+// NewGammaDuration returns a GammaDuration with the given shape and mean number of steps
+func NewGammaDuration(shape, mean float64) GammaDuration {
+	if shape <= 0 || mean < 0 {
+		panic("Gamma durations need a positive shape and a nonnegative mean!")
+	}
+	return GammaDuration{shape: shape, scale: mean / shape}
+}
+
+// NewErlangDuration returns the sum of k exponential durations with a total mean of mean steps.
+// This is what dividing a state into k stages that each last an exponential amount of time gives.
+func NewErlangDuration(k int, mean float64) GammaDuration {
+	return NewGammaDuration(float64(k), mean)
+}
+
+// Mean is the average number of steps before rounding
+func (d GammaDuration) Mean() float64 {
+	return d.shape * d.scale
+}
+
+// sample uses Marsaglia and Tsang's method. Shapes below 1 are sampled with a shape one
+// larger and then scaled down.
+func (d GammaDuration) sample(rand *rand.Rand) int16 {
+	shape := d.shape
+	boost := 1.0
+	if shape < 1 {
+		boost = math.Pow(rand.Float64(), 1/shape)
+		shape++
+	}
+	c1 := shape - 1.0/3
+	c2 := 1 / math.Sqrt(9*c1)
+	for {
+		x := rand.NormFloat64()
+		v := 1 + c2*x
+		if v <= 0 {
+			continue
+		}
+		v = v * v * v
+		u := rand.Float64()
+		if u < 1-.0331*x*x*x*x || math.Log(u) < .5*x*x+c1*(1-v+math.Log(v)) {
+			return toDuration(c1 * v * boost * d.scale)
+		}
+	}
+}
+
+// EmpiricalDuration lasts for k steps with probability proportional to the kth weight.
+// It can be used to match a histogram of observed durations.
+type EmpiricalDuration struct {
+	// cumulative holds the running total of the weights
+	cumulative []float64
+}
+
+// NewEmpiricalDuration returns an EmpiricalDuration with the given weights. The weights don't
+// need to add up to 1, but none of them can be negative and at least one must be positive.
+func NewEmpiricalDuration(weights []float64) EmpiricalDuration {
+	if len(weights) > math.MaxInt16+1 {
+		panic("Too many weights for an empirical duration!")
+	}
+	cumulative := make([]float64, len(weights))
+	total := 0.0
+	for k, weight := range weights {
+		if weight < 0 {
+			panic("Empirical durations can't have negative weights!")
+		}
+		total += weight
+		cumulative[k] = total
+	}
+	if total <= 0 {
+		panic("Empirical durations need a positive weight!")
+	}
+	return EmpiricalDuration{cumulative: cumulative}
+}
+
+// Mean is the average number of steps
+func (d EmpiricalDuration) Mean() float64 {
+	total := d.cumulative[len(d.cumulative)-1]
+	mean := 0.0
+	previous := 0.0
+	for k, cumulative := range d.cumulative {
+		mean += float64(k) * (cumulative - previous) / total
+		previous = cumulative
+	}
+	return mean
+}
+
+func (d EmpiricalDuration) sample(rand *rand.Rand) int16 {
+	target := rand.Float64() * d.cumulative[len(d.cumulative)-1]
+	// the first running total above target, which skips over durations with no weight
+	k := sort.Search(len(d.cumulative), func(i int) bool {
+		return d.cumulative[i] > target
+	})
+	if k == len(d.cumulative) {
+		// rounding can put target at the very end
+		k--
+	}
+	return int16(k)
+}
@@ -0,0 +1,72 @@
+package diseasednetwork
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// TestDurationMeans draws many durations from each distribution and compares their average
+// to the mean the distribution reports
+func TestDurationMeans(t *testing.T) {
+	distributions := map[string]DurationDistribution{
+		"fixed":           NewFixedDuration(4),
+		"geometric":       NewGeometricDuration(4),
+		"small poisson":   NewPoissonDuration(3.5),
+		"large poisson":   NewPoissonDuration(40),
+		"gamma":           NewGammaDuration(2.5, 12),
+		"small gamma":     NewGammaDuration(.5, 20),
+		"erlang":          NewErlangDuration(3, 9),
+		"empirical":       NewEmpiricalDuration([]float64{0, 1, 3, 0, 1}),
+		"zero geometric":  NewGeometricDuration(0),
+		"single weighted": NewEmpiricalDuration([]float64{0, 0, 2}),
+	}
+	rand := rand.New(rand.NewSource(1))
+	numSamples := 20000
+	for name, distribution := range distributions {
+		total := 0.0
+		for i := 0; i < numSamples; i++ {
+			total += float64(distribution.sample(rand))
+		}
+		average := total / float64(numSamples)
+		// rounding the gamma distributions to whole steps moves their averages a little
+		if math.Abs(average-distribution.Mean()) > .03*distribution.Mean()+.05 {
+			t.Errorf("Expected the %s durations to average %f, found %f", name, distribution.Mean(), average)
+		}
+	}
+}
+
+// TestFixedDurations makes sure every node recovers after exactly timeToR steps.
+// The initial infections happen in step 0, so they recover in step timeToR+1.
+func TestFixedDurations(t *testing.T) {
+	adjMat := NewNetwork(50)
+	disease := NewBasicDisease(2, 3, 0, NewInfectN(50))
+	net := NewDiseasedNetwork(&adjMat, []Disease{disease}, nil, []Observer{}, 1)
+	for step := 1; step <= 4; step++ {
+		net.Step()
+		if step < 4 && net.NumInState(StateR, 0) != 0 {
+			t.Errorf("Expected no recoveries in step %d, found %d", step, net.NumInState(StateR, 0))
+		}
+	}
+	if net.NumInState(StateR, 0) != 50 {
+		t.Errorf("Expected every node to recover in step 4, found %d", net.NumInState(StateR, 0))
+	}
+}
+
+// TestStochasticDurations makes sure nodes with the same disease can recover at different times
+func TestStochasticDurations(t *testing.T) {
+	adjMat := NewNetwork(500)
+	disease := NewBasicDiseaseWithDurations(NewFixedDuration(1), NewPoissonDuration(6), 0, NewInfectN(500))
+	net := NewDiseasedNetwork(&adjMat, []Disease{disease}, nil, []Observer{}, 1)
+	recoveredBySteps := make([]int, 0)
+	for step := 0; step < 30; step++ {
+		net.Step()
+		recoveredBySteps = append(recoveredBySteps, net.NumInState(StateR, 0))
+	}
+	if recoveredBySteps[2] == 0 || recoveredBySteps[5] == 500 {
+		t.Errorf("Expected recoveries to be spread out, found %v", recoveredBySteps)
+	}
+	if recoveredBySteps[29] != 500 {
+		t.Errorf("Expected every node to recover within 30 steps, found %d", recoveredBySteps[29])
+	}
+}
@@ -0,0 +1,204 @@
+package diseasednetwork
+
+import "math"
+
+// Engine chooses how a DiseasedNetwork moves its diseases forward during a step
+type Engine int
+
+const (
+	// DiscreteEngine gives every infectious node one chance per step to infect each of its
+	// susceptible neighbors, and changes a node's state after the number of steps drawn from
+	// the disease's DurationDistribution
+	DiscreteEngine Engine = iota
+	// GillespieEngine simulates each step as one unit of continuous time. Transmission across
+	// each edge and every change of state happen at exponentially distributed times, and the
+	// events are played out in order with the Gillespie algorithm.
+	// The time spent in a state is exponential with the same mean as the disease's
+	// DurationDistribution, so the chance of crossing an edge in a single step is the same in
+	// both engines, but usually not over a whole infection. Expect the engines to differ most
+	// near the epidemic threshold.
+	GillespieEngine
+)
+
+func (e Engine) String() string {
+	switch e {
+	case DiscreteEngine:
+		return "discrete"
+	case GillespieEngine:
+		return "gillespie"
+	}
+	return "unknown"
+}
+
+// transmissionRate converts the probability that an infectious node infects a neighbor during
+// one step into the rate of an exponential process with the same chance of firing within one
+// unit of time. That way both engines agree on the risk across a single edge.
+// A probability of 1 has no finite rate, so it is treated as the largest probability below 1.
+func transmissionRate(infectionProbability float32) float64 {
+	p := math.Min(float64(infectionProbability), math.Nextafter(1, 0))
+	return -math.Log1p(-p)
+}
+
+// durationRate gives the rate of leaving a state that lasts for steps steps on average.
+// States that last 0 steps are left immediately, which is an infinite rate.
+func durationRate(steps float64) float64 {
+	if steps <= 0 {
+		return math.Inf(1)
+	}
+	return 1 / steps
+}
+
+// maxDegree returns the largest degree in the network
+func (n *DiseasedNetwork) maxDegree() int {
+	max := 0
+	for node := 0; node < n.NumNodes(); node++ {
+		if degree := n.adjMat.Degree(node); degree > max {
+			max = degree
+		}
+	}
+	return max
+}
+
+// runContinuousTime plays out one unit of time for every disease with the Gillespie algorithm.
+// The network doesn't change during the unit, so each disease's events can be sampled exactly.
+// Transmission is sampled by rejection: the total transmission rate is bounded by assuming every
+// infectious node has the maximum degree and every neighbor is susceptible across an edge with
+// the highest rate. An event picks an infectious node and one of maxDegree slots, and only infects
+// if the slot holds a susceptible neighbor, and then only with the edge's share of the highest
+// rate. The wasted events don't change the distribution of the real ones.
+// The process is memoryless, so stopping at the end of the unit and starting again in the next
+// step is exactly the same as running without stopping.
+func (n *DiseasedNetwork) runContinuousTime() {
+	maxDegree := n.maxDegree()
+	for i, disease := range n.diseases {
+		model := disease.model()
+		beta := n.maxTransmissionRate(i)
+		rates := make([]float64, len(model.Compartments))
+		for state, c := range model.Compartments {
+			if c.Duration != nil {
+				rates[state] = durationRate(c.Duration.Mean())
+			}
+		}
+
+		t := 0.0
+		for {
+			n.settleInstantStates(disease, rates)
+			infectionRate := beta * float64(maxDegree) * float64(numInfectious(disease))
+			totalRate := infectionRate
+			for state, rate := range rates {
+				if disease.NumInState(state) > 0 {
+					totalRate += rate * float64(disease.NumInState(state))
+				}
+			}
+			if totalRate == 0 {
+				break
+			}
+			t += n.rand.ExpFloat64() / totalRate
+			if t >= 1 {
+				break
+			}
+
+			r := n.rand.Float64() * totalRate
+			if r < infectionRate {
+				n.tryTransmission(i, maxDegree, beta)
+				continue
+			}
+			r -= infectionRate
+			for state, rate := range rates {
+				if disease.NumInState(state) == 0 {
+					continue
+				}
+				stateRate := rate * float64(disease.NumInState(state))
+				if r < stateRate {
+					nodes := disease.NodesInState(state)
+					node := nodes[n.rand.Intn(len(nodes))]
+					disease.SetState(int(node), model.nextCompartment(uint8(state), n.rand))
+					break
+				}
+				r -= stateRate
+			}
+		}
+	}
+}
+
+// numInfectious counts the nodes in all of the infectious compartments of disease
+func numInfectious(disease Disease) int {
+	total := 0
+	for _, state := range disease.model().infectiousStates {
+		total += disease.NumInState(int(state))
+	}
+	return total
+}
+
+// maxTransmissionRate gives the highest rate the disease at diseaseIndex can cross any edge
+// in the network at
+func (n *DiseasedNetwork) maxTransmissionRate(diseaseIndex int) float64 {
+	disease := n.diseases[diseaseIndex]
+	multiplier := n.maxMultiplier * n.maxInteractionMultiplier(diseaseIndex)
+	max := 0.0
+	for weight := 1; weight <= int(n.adjMat.maxWeight); weight++ {
+		p := n.transmission(disease.InfectionProbability(), uint8(weight)) * multiplier
+		rate := transmissionRate(float32(math.Min(1, float64(p))))
+		if rate > max {
+			max = rate
+		}
+	}
+	return max
+}
+
+// tryTransmission picks a random infectious node and a random slot in its list of neighbors.
+// If the slot holds a susceptible neighbor, the neighbor is infected with probability
+// rate/maxRate, where rate is the transmission rate across the edge between them.
+func (n *DiseasedNetwork) tryTransmission(diseaseIndex int, maxDegree int, maxRate float64) {
+	disease := n.diseases[diseaseIndex]
+	model := disease.model()
+	// find the chosen node among the infectious compartments
+	infector := -1
+	choice := n.rand.Intn(numInfectious(disease))
+	for _, state := range model.infectiousStates {
+		nodes := disease.NodesInState(int(state))
+		if choice < len(nodes) {
+			infector = int(nodes[choice])
+			break
+		}
+		choice -= len(nodes)
+	}
+
+	slot := n.rand.Intn(maxDegree)
+	neighbors := n.adjMat.Neighbors(infector)
+	if slot >= len(neighbors) {
+		return
+	}
+	atRiskNode := int(neighbors[slot])
+	if !model.susceptible[disease.State(atRiskNode)] {
+		return
+	}
+	// when every edge has the highest rate no random number is needed
+	rate := transmissionRate(n.edgeProbability(diseaseIndex, infector, atRiskNode))
+	if rate < maxRate && n.rand.Float64()*maxRate >= rate {
+		return
+	}
+	disease.SetState(atRiskNode, model.Exposure)
+	disease.recordInfection(infector, atRiskNode)
+}
+
+// settleInstantStates moves every node out of the states that are left immediately.
+// Afterwards the states with infinite rates are empty, so they can be skipped when the
+// rates are summed.
+func (n *DiseasedNetwork) settleInstantStates(disease Disease, rates []float64) {
+	model := disease.model()
+	moved := true
+	for moved {
+		moved = false
+		for state, rate := range rates {
+			if !math.IsInf(rate, 1) || disease.NumInState(state) == 0 {
+				continue
+			}
+			nodes := append([]int32(nil), disease.NodesInState(state)...)
+			for _, node := range nodes {
+				disease.SetState(int(node), model.nextCompartment(uint8(state), n.rand))
+			}
+			moved = true
+		}
+	}
+}
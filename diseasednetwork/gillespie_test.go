@@ -0,0 +1,80 @@
+package diseasednetwork
+
+import (
+	"math"
+	"testing"
+)
+
+// TestGillespieRecovery makes sure infected nodes that can't infect anyone recover
+// at the rate given by the disease
+func TestGillespieRecovery(t *testing.T) {
+	numNodes := 2000
+	adjMat := NewNetwork(numNodes)
+	timeToR := int16(5)
+	net := NewDiseasedNetwork(&adjMat, []Disease{NewBasicDisease(1, timeToR, .5, NewInfectN(numNodes))},
+		nil, []Observer{}, 11)
+	net.SetEngine(GillespieEngine)
+	for step := 0; step < int(timeToR); step++ {
+		net.Step()
+	}
+
+	// after the average time to recover, 1 - 1/e of the nodes should have recovered
+	expected := 1 - math.Exp(-1)
+	recovered := float64(net.NumInState(StateR, 0)) / float64(numNodes)
+	if math.Abs(recovered-expected) > .03 {
+		t.Errorf("Expected about %f of the nodes to recover, found %f", expected, recovered)
+	}
+}
+
+// TestGillespieTransmissibility infects one node in each of many isolated pairs and makes sure
+// the other node is infected as often as two competing exponential processes predict
+func TestGillespieTransmissibility(t *testing.T) {
+	numPairs := 1000
+	adjMat := NewNetwork(2 * numPairs)
+	for pair := 0; pair < numPairs; pair++ {
+		adjMat.AddEdge(2*pair, 2*pair+1, 1)
+	}
+	timeToR := int16(4)
+	disease := NewBasicDisease(1, timeToR, .2, NewInfectN(0))
+	net := NewDiseasedNetwork(&adjMat, []Disease{disease}, nil, []Observer{}, 5)
+	net.SetEngine(GillespieEngine)
+	for pair := 0; pair < numPairs; pair++ {
+		disease.SetState(2*pair, StateI)
+	}
+	for step := 0; step < 100; step++ {
+		net.Step()
+	}
+
+	beta := transmissionRate(.2)
+	gamma := 1 / float64(timeToR)
+	expected := beta / (beta + gamma)
+	found := net.InfectionTree(0).R0()
+	if math.Abs(found-expected) > .05 {
+		t.Errorf("Expected %f of the pairs to transmit, found %f", expected, found)
+	}
+}
+
+func TestTransmissionRate(t *testing.T) {
+	for _, p := range []float32{0, .1, .5, .9} {
+		// the chance of at least one event in a unit of time
+		fired := 1 - math.Exp(-transmissionRate(p))
+		if math.Abs(fired-float64(p)) > 1e-6 {
+			t.Errorf("Expected a rate that fires with probability %f, found %f", p, fired)
+		}
+	}
+	if math.IsInf(transmissionRate(1), 0) {
+		t.Error("Expected a finite rate for a probability of 1")
+	}
+}
+
+// TestGillespieInstantStates makes sure states that last 0 steps are skipped
+func TestGillespieInstantStates(t *testing.T) {
+	adjMat := NewNetwork(10)
+	net := NewDiseasedNetwork(&adjMat, []Disease{NewBasicDisease(1, 0, .5, NewInfectN(10))},
+		nil, []Observer{}, 11)
+	net.SetEngine(GillespieEngine)
+	net.Step()
+	if net.NumInState(StateR, 0) != 10 {
+		t.Errorf("Expected every node to recover immediately, found %d recovered", net.NumInState(StateR, 0))
+	}
+}
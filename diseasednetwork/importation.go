@@ -0,0 +1,104 @@
+package diseasednetwork
+
+import "math/rand"
+
+// Importation brings infections into the network from outside during a run. Imported infections
+// put random susceptible nodes in the bad disease's (slot 0) exposure compartment at the start of
+// a step. Imported cases have no infector, but they aren't index cases, so InfectionTree.R0 leaves
+// them out.
+// Nodes with a susceptibility of 0 are never chosen.
+type Importation interface {
+	// numImports returns how many nodes should be exposed in step. It should get all of its
+	// randomness from rand so that simulations can be replayed.
+	numImports(step uint, rand *rand.Rand) int
+}
+
+// ConstantImportation imports infections at a constant rate. The number in each step is
+// Poisson distributed.
+type ConstantImportation struct {
+	perStep PoissonDuration
+}
+
+// NewConstantImportation returns a ConstantImportation that imports rate infections per step
+// on average
+func NewConstantImportation(rate float64) ConstantImportation {
+	if rate < 0 {
+		panic("Infections can't be imported at a negative rate!")
+	}
+	return ConstantImportation{perStep: NewPoissonDuration(rate)}
+}
+
+func (c ConstantImportation) numImports(step uint, rand *rand.Rand) int {
+	return int(c.perStep.sample(rand))
+}
+
+// PulseImportation imports n infections in step start and then every interval steps until it
+// has done so numPulses times
+type PulseImportation struct {
+	n         int
+	start     uint
+	interval  uint
+	numPulses int
+}
+
+// NewPulseImportation returns an instance of PulseImportation
+func NewPulseImportation(n int, start, interval uint, numPulses int) PulseImportation {
+	if interval == 0 {
+		panic("Pulses must be at least one step apart!")
+	}
+	return PulseImportation{n: n, start: start, interval: interval, numPulses: numPulses}
+}
+
+func (p PulseImportation) numImports(step uint, rand *rand.Rand) int {
+	if step < p.start || (step-p.start)%p.interval != 0 || (step-p.start)/p.interval >= uint(p.numPulses) {
+		return 0
+	}
+	return p.n
+}
+
+// ScheduledImportation imports the number of infections listed for each step
+type ScheduledImportation struct {
+	counts map[uint]int
+}
+
+// NewScheduledImportation returns a ScheduledImportation that imports counts[step] infections in
+// each step that is in counts
+func NewScheduledImportation(counts map[uint]int) ScheduledImportation {
+	schedule := make(map[uint]int, len(counts))
+	for step, count := range counts {
+		schedule[step] = count
+	}
+	return ScheduledImportation{counts: schedule}
+}
+
+func (s ScheduledImportation) numImports(step uint, rand *rand.Rand) int {
+	return s.counts[step]
+}
+
+// SetImportations replaces the ways infections are brought into the network.
+// They are applied in order at the start of every step. nil stops importations.
+func (n *DiseasedNetwork) SetImportations(importations []Importation) {
+	n.importations = importations
+}
+
+// importInfections exposes the nodes the importations ask for in this step. If there aren't
+// enough susceptible nodes, every one of them is exposed.
+func (n *DiseasedNetwork) importInfections() {
+	step := n.stepNum + 1
+	for _, importation := range n.importations {
+		count := importation.numImports(step, n.rand)
+		if count <= 0 {
+			continue
+		}
+		disease := n.diseases[0]
+		model := disease.model()
+		candidates := make([]bool, n.NumNodes())
+		for node := range candidates {
+			candidates[node] = model.susceptible[disease.State(node)] &&
+				(n.adjMat.attributes == nil || n.adjMat.attributes[node].Susceptibility > 0)
+		}
+		for _, node := range chooseRandom(markedNodes(candidates), count, n.rand) {
+			disease.SetState(node, model.Exposure)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package diseasednetwork
+
+import (
+	"math"
+	"testing"
+)
+
+// countImports steps a network that the disease can't spread on and returns the number of
+// infected nodes after each step
+func countImports(t *testing.T, numNodes, numSteps int, importations ...Importation) []int {
+	net, disease := infectedBy(t, makePath(numNodes), NewInfectN(0))
+	net.SetImportations(importations)
+	counts := make([]int, numSteps)
+	for step := range counts {
+		net.Step()
+		counts[step] = disease.NumInState(StateI)
+	}
+	return counts
+}
+
+func TestPulseImportation(t *testing.T) {
+	counts := countImports(t, 100, 8, NewPulseImportation(2, 3, 2, 3))
+	expected := []int{0, 0, 2, 2, 4, 4, 6, 6}
+	for step := range expected {
+		if counts[step] != expected[step] {
+			t.Errorf("Expected %v infected after each step, found %v", expected, counts)
+			break
+		}
+	}
+}
+
+func TestScheduledImportation(t *testing.T) {
+	counts := countImports(t, 100, 5, NewScheduledImportation(map[uint]int{1: 5, 4: 3, 9: 100}))
+	expected := []int{5, 5, 5, 8, 8}
+	for step := range expected {
+		if counts[step] != expected[step] {
+			t.Errorf("Expected %v infected after each step, found %v", expected, counts)
+			break
+		}
+	}
+
+	counts = countImports(t, 10, 2, NewScheduledImportation(map[uint]int{1: 4, 2: 20}))
+	if counts[1] != 10 {
+		t.Errorf("Expected every node to be infected when too many are imported, found %d", counts[1])
+	}
+}
+
+func TestConstantImportation(t *testing.T) {
+	counts := countImports(t, 2000, 50, NewConstantImportation(3))
+	if math.Abs(float64(counts[49])-150) > 40 {
+		t.Errorf("Expected about 150 imported infections, found %d", counts[49])
+	}
+}
+
+func TestImportationSkipsUnsusceptible(t *testing.T) {
+	adjMat := makePath(100)
+	attributes := DefaultAttributes(100)
+	for node := 0; node < 50; node++ {
+		attributes[node].Susceptibility = 0
+	}
+	adjMat.SetNodeAttributes(attributes)
+	net, disease := infectedBy(t, adjMat, NewInfectN(0))
+	net.SetImportations([]Importation{NewScheduledImportation(map[uint]int{1: 80})})
+	net.Step()
+	if disease.NumInState(StateI) != 50 {
+		t.Errorf("Expected the 50 susceptible nodes to be infected, found %d", disease.NumInState(StateI))
+	}
+	for node := 0; node < 50; node++ {
+		if disease.State(node) != StateS {
+			t.Errorf("Node %d has no susceptibility but was infected", node)
+		}
+	}
+}
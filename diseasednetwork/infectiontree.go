@@ -0,0 +1,193 @@
+package diseasednetwork
+
+// InfectionCase is one infection of one node. A node that is infected more than once
+// has a separate case for each infection.
+type InfectionCase struct {
+	Node int
+	// Infector is the index of the case that caused this one, or -1 for cases that
+	// weren't caused by another node, such as the initial infections and imported infections
+	Infector int
+	// Generation is 0 for cases without an infector and one more than the infector's otherwise
+	Generation int
+	// Step is the time step the node was infected in. The initial infections happen in step 0,
+	// and the first call to DiseasedNetwork.Step is step 1.
+	Step int
+	// Offspring is the number of cases this case caused
+	Offspring int
+	// Resolved is true once the node is no longer exposed or infectious
+	Resolved bool
+}
+
+// IsInitial reports whether the case is one of the initial infections. Those are the cases in
+// step 0 that weren't caused by another node.
+func (c InfectionCase) IsInitial() bool {
+	return c.Infector < 0 && c.Step == 0
+}
+
+// InfectionTree records who infected whom over an entire run of a disease
+type InfectionTree struct {
+	cases []InfectionCase
+	// currentCase is the index of each node's ongoing case or -1 if it has none
+	currentCase []int32
+	step        int
+	// infected is true for the states that are part of a case. It is shared by copies of the tree.
+	infected []bool
+}
+
+// newInfectionTree makes a tree for a disease where the states that are part of a case are
+// marked in infected
+func newInfectionTree(numNodes int, infected []bool) *InfectionTree {
+	tree := InfectionTree{currentCase: make([]int32, numNodes), infected: infected}
+	for node := range tree.currentCase {
+		tree.currentCase[node] = -1
+	}
+	return &tree
+}
+
+// stateChanged starts a new case when a node becomes infected and resolves its case
+// when it stops being infected
+func (t *InfectionTree) stateChanged(node int, from, to uint8) {
+	if !t.infected[from] && t.infected[to] {
+		t.currentCase[node] = int32(len(t.cases))
+		t.cases = append(t.cases, InfectionCase{Node: node, Infector: -1, Step: t.step})
+	} else if t.infected[from] && !t.infected[to] {
+		t.cases[t.currentCase[node]].Resolved = true
+		t.currentCase[node] = -1
+	}
+}
+
+// recordInfection makes infector's case the parent of infected's case.
+// It must be called after infected's state has been changed.
+func (t *InfectionTree) recordInfection(infector, infected int) {
+	parent := t.currentCase[infector]
+	child := t.currentCase[infected]
+	if parent < 0 || child < 0 {
+		panic("Infections can only be recorded between infected nodes!")
+	}
+	t.cases[parent].Offspring++
+	t.cases[child].Infector = int(parent)
+	t.cases[child].Generation = t.cases[parent].Generation + 1
+}
+
+// beginStep moves the tree on to the next time step
+func (t *InfectionTree) beginStep() {
+	t.step++
+}
+
+// Cases returns every case in the order they started. The slice must not be modified.
+func (t *InfectionTree) Cases() []InfectionCase {
+	return t.cases
+}
+
+// R0 estimates the basic reproduction number as the average number of nodes infected by
+// the initial infections that have resolved. They are the only cases that had a fully
+// susceptible network around them. Cases without an infector that start after step 0, like
+// imported infections or later waves of StaggeredInfection, are left out because the nodes
+// around them may already be recovered. It is 0 if none of the initial infections have resolved.
+func (t *InfectionTree) R0() float64 {
+	numCases := 0
+	numOffspring := 0
+	for _, c := range t.cases {
+		if c.IsInitial() && c.Resolved {
+			numCases++
+			numOffspring += c.Offspring
+		}
+	}
+	if numCases == 0 {
+		return 0
+	}
+	return float64(numOffspring) / float64(numCases)
+}
+
+// CaseReproductionNumber is the average number of nodes infected by each resolved case,
+// including the cases that infected no one. Unresolved cases are left out because they
+// may still infect more nodes. It is 0 if no cases have resolved.
+func (t *InfectionTree) CaseReproductionNumber() float64 {
+	numCases := 0
+	numOffspring := 0
+	for _, c := range t.cases {
+		if c.Resolved {
+			numCases++
+			numOffspring += c.Offspring
+		}
+	}
+	if numCases == 0 {
+		return 0
+	}
+	return float64(numOffspring) / float64(numCases)
+}
+
+// GenerationR returns the number of cases in each generation divided by the number
+// in the generation before it. The entry at index g is for generation g+1 over generation g.
+func (t *InfectionTree) GenerationR() []float64 {
+	sizes := make([]int, 0)
+	for _, c := range t.cases {
+		for len(sizes) <= c.Generation {
+			sizes = append(sizes, 0)
+		}
+		sizes[c.Generation]++
+	}
+	if len(sizes) < 2 {
+		return []float64{}
+	}
+	r := make([]float64, len(sizes)-1)
+	for g := range r {
+		r[g] = float64(sizes[g+1]) / float64(sizes[g])
+	}
+	return r
+}
+
+// EffectiveR returns the effective reproduction number for each time step: the average number
+// of nodes infected by the cases that started in that step. Steps without any new cases are 0.
+// Cases from the last few steps may not have finished infecting nodes yet, so the end of the
+// slice underestimates the true value.
+func (t *InfectionTree) EffectiveR() []float64 {
+	numCases := make([]int, t.step+1)
+	numOffspring := make([]int, t.step+1)
+	for _, c := range t.cases {
+		numCases[c.Step]++
+		numOffspring[c.Step] += c.Offspring
+	}
+	rt := make([]float64, t.step+1)
+	for step := range rt {
+		if numCases[step] > 0 {
+			rt[step] = float64(numOffspring[step]) / float64(numCases[step])
+		}
+	}
+	return rt
+}
+
+// OffspringDistribution returns how many resolved cases infected exactly k nodes at index k
+func (t *InfectionTree) OffspringDistribution() []int {
+	distribution := make([]int, 0)
+	for _, c := range t.cases {
+		if !c.Resolved {
+			continue
+		}
+		for len(distribution) <= c.Offspring {
+			distribution = append(distribution, 0)
+		}
+		distribution[c.Offspring]++
+	}
+	return distribution
+}
+
+// copyTree copies t, which may be nil if the disease hasn't been given its number of nodes yet
+func copyTree(t *InfectionTree) *InfectionTree {
+	if t == nil {
+		return nil
+	}
+	return t.makeCopy()
+}
+
+func (t *InfectionTree) makeCopy() *InfectionTree {
+	c := InfectionTree{
+		cases:       make([]InfectionCase, len(t.cases)),
+		currentCase: make([]int32, len(t.currentCase)),
+		step:        t.step,
+		infected:    t.infected,
+	}
+	copy(c.cases, t.cases)
+	copy(c.currentCase, t.currentCase)
+	return &c
+}
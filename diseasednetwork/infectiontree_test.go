@@ -0,0 +1,120 @@
+package diseasednetwork
+
+import "testing"
+
+// newSEIRTree makes a tree that treats E and I as infected
+func newSEIRTree(numNodes int) *InfectionTree {
+	return newInfectionTree(numNodes, []bool{StateE: true, StateI: true, StateR: false})
+}
+
+// makeTestTree builds this tree over two steps:
+// step 0: 0 and 1 are infected from outside
+// step 1: 0 infects 2 and 3
+// step 2: 2 infects 4, and 0, 1 and 2 recover
+func makeTestTree() *InfectionTree {
+	tree := newSEIRTree(5)
+	tree.stateChanged(0, StateS, StateI)
+	tree.stateChanged(1, StateS, StateI)
+	tree.beginStep()
+	for _, node := range []int{2, 3} {
+		tree.stateChanged(node, StateS, StateE)
+		tree.recordInfection(0, node)
+		tree.stateChanged(node, StateE, StateI)
+	}
+	tree.beginStep()
+	tree.stateChanged(4, StateS, StateE)
+	tree.recordInfection(2, 4)
+	for _, node := range []int{0, 1, 2} {
+		tree.stateChanged(node, StateI, StateR)
+	}
+	return tree
+}
+
+func TestInfectionTree(t *testing.T) {
+	tree := makeTestTree()
+	cases := tree.Cases()
+	if len(cases) != 5 {
+		t.Fatalf("Expected 5 cases, found %d", len(cases))
+	}
+	if cases[4].Node != 4 || cases[4].Infector != 2 || cases[4].Generation != 2 || cases[4].Step != 2 {
+		t.Errorf("Expected node 4 to be infected by case 2 in generation 2 and step 2, found %+v", cases[4])
+	}
+	// only the cases of 0, 1 and 2 have resolved
+	if r0 := tree.R0(); r0 != 1 {
+		t.Errorf("Expected R0 of 1 from the index cases, found %f", r0)
+	}
+	if r := tree.CaseReproductionNumber(); r != 1 {
+		t.Errorf("Expected a case reproduction number of 1, found %f", r)
+	}
+	expectFloats(t, "generation R", tree.GenerationR(), []float64{1, .5})
+	expectFloats(t, "effective R", tree.EffectiveR(), []float64{1, .5, 0})
+	distribution := tree.OffspringDistribution()
+	if len(distribution) != 3 || distribution[0] != 1 || distribution[1] != 1 || distribution[2] != 1 {
+		t.Errorf("Expected offspring distribution [1 1 1], found %v", distribution)
+	}
+}
+
+// TestReinfection makes sure a node that is infected twice gets two cases
+func TestReinfection(t *testing.T) {
+	tree := newSEIRTree(2)
+	tree.stateChanged(0, StateS, StateI)
+	tree.stateChanged(1, StateS, StateI)
+	tree.stateChanged(1, StateI, StateS)
+	tree.beginStep()
+	tree.stateChanged(1, StateS, StateE)
+	tree.recordInfection(0, 1)
+	if len(tree.Cases()) != 3 || tree.Cases()[2].Infector != 0 || !tree.Cases()[1].Resolved {
+		t.Errorf("Expected the second infection of node 1 to be a new case, found %+v", tree.Cases())
+	}
+}
+
+// TestR0IgnoresImports makes sure cases from outside that start after step 0 don't count as
+// initial infections even though they have no infector
+func TestR0IgnoresImports(t *testing.T) {
+	tree := newSEIRTree(3)
+	tree.stateChanged(0, StateS, StateI)
+	tree.beginStep()
+	tree.stateChanged(1, StateS, StateE)
+	tree.recordInfection(0, 1)
+	tree.stateChanged(2, StateS, StateE)
+	tree.stateChanged(0, StateI, StateR)
+	tree.stateChanged(1, StateE, StateR)
+	tree.stateChanged(2, StateE, StateR)
+	if cases := tree.Cases(); !cases[0].IsInitial() || cases[2].IsInitial() || cases[2].Infector != -1 {
+		t.Errorf("Expected only the first case to be initial, found %+v", cases)
+	}
+	if r0 := tree.R0(); r0 != 1 {
+		t.Errorf("Expected an R0 of 1 from the initial infection alone, found %f", r0)
+	}
+}
+
+// TestTreeInNetwork makes sure every node in a complete network is credited to the one
+// initial infection even though they were all at risk from each other
+func TestTreeInNetwork(t *testing.T) {
+	numNodes := 50
+	network := makeCompleteNetwork(numNodes)
+	dis := NewBasicDisease(1, 1, 1.0, NewInfectN(1))
+	diseasedNet := NewDiseasedNetwork(&network, []Disease{dis}, nil, []Observer{}, 1)
+	for i := 0; i < 4; i++ {
+		diseasedNet.Step()
+	}
+	if len(dis.InfectionTree().Cases()) != numNodes {
+		t.Errorf("Expected %d cases, found %d", numNodes, len(dis.InfectionTree().Cases()))
+	}
+	if dis.R0() != float64(numNodes-1) {
+		t.Errorf("Expected R0 of %d, found %f", numNodes-1, dis.R0())
+	}
+}
+
+func expectFloats(t *testing.T, name string, actual, expected []float64) {
+	if len(actual) != len(expected) {
+		t.Errorf("Expected %s to be %v, found %v", name, expected, actual)
+		return
+	}
+	for i := range expected {
+		if actual[i] != expected[i] {
+			t.Errorf("Expected %s to be %v, found %v", name, expected, actual)
+			return
+		}
+	}
+}
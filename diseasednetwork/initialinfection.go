@@ -1,16 +1,35 @@
 package diseasednetwork
 
 import (
+	"fmt"
+	"math"
 	"math/rand"
-	"time"
 )
 
-// InitialInfectionStrategy is used to seed a DiseasedNetwork with some number of infected nodes
+// InitialInfectionStrategy is used to seed a DiseasedNetwork with some number of infected nodes.
+// The nodes are put in the disease's seed compartment.
 type InitialInfectionStrategy interface {
-	apply(disease Disease)
+	// apply infects the first nodes when the DiseasedNetwork is made. It should get all of its
+	// randomness from rand so that simulations can be replayed.
+	apply(n *DiseasedNetwork, disease Disease, rand *rand.Rand)
 }
 
-// InfectN chooses n random nodes to infect
+// ValidatedStrategy is an InitialInfectionStrategy that only works on some networks.
+// Check it with Validate once the network is known instead of waiting for apply to panic.
+type ValidatedStrategy interface {
+	InitialInfectionStrategy
+	// Validate returns an error if the strategy can't be applied to a network with numNodes nodes
+	Validate(numNodes int) error
+}
+
+// laterInfectionStrategy is an InitialInfectionStrategy that keeps infecting nodes after the start
+type laterInfectionStrategy interface {
+	InitialInfectionStrategy
+	// applyLater is called at the start of every step, before anything else happens
+	applyLater(n *DiseasedNetwork, disease Disease, step uint, rand *rand.Rand)
+}
+
+// InfectN chooses n random nodes to infect. Every node is infected if n is larger than the network.
 type InfectN struct {
 	n int
 }
@@ -21,14 +40,198 @@ func NewInfectN(n int) InfectN {
 }
 
 // apply the infection strategy to a DiseadedNetwork
-func (i InfectN) apply(disease Disease) {
-	rand := rand.New(rand.NewSource(time.Now().UnixNano()))
+func (i InfectN) apply(n *DiseasedNetwork, disease Disease, rand *rand.Rand) {
+	numToInfect := i.n
+	if numToInfect > disease.NumNodes() {
+		numToInfect = disease.NumNodes()
+	}
 	infectedNodes := make(map[int]bool)
-	for len(infectedNodes) < i.n {
+	for len(infectedNodes) < numToInfect {
 		nodeToInfect := rand.Intn(disease.NumNodes())
 		if !infectedNodes[nodeToInfect] {
 			infectedNodes[nodeToInfect] = true
-			disease.SetState(nodeToInfect, StateI)
+			disease.SetState(nodeToInfect, disease.model().Seed)
 		}
 	}
 }
+
+// InfectFraction infects a random fraction of the nodes, rounded to the nearest node
+type InfectFraction struct {
+	fraction float64
+}
+
+// NewInfectFraction returns an instance of the InfectFraction infection strategy
+func NewInfectFraction(fraction float64) InfectFraction {
+	if fraction < 0 || fraction > 1 {
+		panic("The fraction of nodes to infect must be between 0 and 1!")
+	}
+	return InfectFraction{fraction: fraction}
+}
+
+func (i InfectFraction) apply(n *DiseasedNetwork, disease Disease, rand *rand.Rand) {
+	numToInfect := int(math.Round(i.fraction * float64(disease.NumNodes())))
+	NewInfectN(numToInfect).apply(n, disease, rand)
+}
+
+// InfectNodes infects specific nodes
+type InfectNodes struct {
+	nodes []int
+}
+
+// NewInfectNodes returns an instance of the InfectNodes infection strategy
+func NewInfectNodes(nodes ...int) InfectNodes {
+	return InfectNodes{nodes: append([]int(nil), nodes...)}
+}
+
+// Validate returns an error for the first node that isn't in a network with numNodes nodes
+func (i InfectNodes) Validate(numNodes int) error {
+	for _, node := range i.nodes {
+		if node < 0 || node >= numNodes {
+			return fmt.Errorf("node %d isn't in a network of %d nodes", node, numNodes)
+		}
+	}
+	return nil
+}
+
+// apply panics if any of the nodes aren't in the network. Use Validate to check first.
+func (i InfectNodes) apply(n *DiseasedNetwork, disease Disease, rand *rand.Rand) {
+	if i.Validate(disease.NumNodes()) != nil {
+		panic("Can't infect a node that isn't in the network!")
+	}
+	for _, node := range i.nodes {
+		disease.SetState(node, disease.model().Seed)
+	}
+}
+
+// InfectHighestDegree infects the n nodes with the most neighbors. Ties go to the node with the
+// lower index.
+type InfectHighestDegree struct {
+	n int
+}
+
+// NewInfectHighestDegree returns an instance of the InfectHighestDegree infection strategy
+func NewInfectHighestDegree(n int) InfectHighestDegree {
+	return InfectHighestDegree{n: n}
+}
+
+func (i InfectHighestDegree) apply(n *DiseasedNetwork, disease Disease, rand *rand.Rand) {
+	everyNode := make([]bool, n.NumNodes())
+	for node := range everyNode {
+		everyNode[node] = true
+	}
+	nodes := highestScores(everyNode, i.n, func(node int) float64 {
+		return float64(n.Degree(node))
+	})
+	for _, node := range nodes {
+		disease.SetState(node, disease.model().Seed)
+	}
+}
+
+// InfectCluster infects a random node and then the nodes closest to it until n nodes are infected.
+// If the random node's component is too small, another random node is chosen to continue from.
+type InfectCluster struct {
+	n int
+}
+
+// NewInfectCluster returns an instance of the InfectCluster infection strategy
+func NewInfectCluster(n int) InfectCluster {
+	return InfectCluster{n: n}
+}
+
+func (i InfectCluster) apply(n *DiseasedNetwork, disease Disease, rand *rand.Rand) {
+	numToInfect := i.n
+	if numToInfect > n.NumNodes() {
+		numToInfect = n.NumNodes()
+	}
+	infected := make([]bool, n.NumNodes())
+	numInfected := 0
+	for numInfected < numToInfect {
+		center := rand.Intn(n.NumNodes())
+		if infected[center] {
+			continue
+		}
+		cluster := append([]int{center}, n.ring(center, n.NumNodes())...)
+		for _, node := range cluster {
+			if numInfected == numToInfect {
+				break
+			}
+			if !infected[node] {
+				infected[node] = true
+				numInfected++
+				disease.SetState(node, disease.model().Seed)
+			}
+		}
+	}
+}
+
+// InfectGroup infects n random nodes from one group of the network's node attributes.
+// Every node in the group is infected if there are fewer than n.
+type InfectGroup struct {
+	group int
+	n     int
+}
+
+// NewInfectGroup returns an instance of the InfectGroup infection strategy
+func NewInfectGroup(group, n int) InfectGroup {
+	return InfectGroup{group: group, n: n}
+}
+
+func (i InfectGroup) apply(n *DiseasedNetwork, disease Disease, rand *rand.Rand) {
+	inGroup := make([]bool, n.NumNodes())
+	for node := range inGroup {
+		inGroup[node] = n.Group(node) == i.group
+	}
+	infectRandom(disease, inGroup, i.n, rand)
+}
+
+// StaggeredInfection infects n random susceptible nodes at the start and then again every interval
+// steps until it has done so numWaves times
+type StaggeredInfection struct {
+	n        int
+	interval uint
+	numWaves int
+}
+
+// NewStaggeredInfection returns an instance of the StaggeredInfection infection strategy
+func NewStaggeredInfection(n int, interval uint, numWaves int) StaggeredInfection {
+	if interval == 0 {
+		panic("Waves of infection must be at least one step apart!")
+	}
+	return StaggeredInfection{n: n, interval: interval, numWaves: numWaves}
+}
+
+func (s StaggeredInfection) apply(n *DiseasedNetwork, disease Disease, rand *rand.Rand) {
+	s.applyLater(n, disease, 0, rand)
+}
+
+func (s StaggeredInfection) applyLater(n *DiseasedNetwork, disease Disease, step uint, rand *rand.Rand) {
+	if step%s.interval != 0 || step/s.interval >= uint(s.numWaves) {
+		return
+	}
+	susceptible := disease.model().susceptible
+	candidates := make([]bool, disease.NumNodes())
+	for node := range candidates {
+		candidates[node] = susceptible[disease.State(node)]
+	}
+	infectRandom(disease, candidates, s.n, rand)
+}
+
+// infectRandom infects up to count random nodes from the ones marked in candidates
+func infectRandom(disease Disease, candidates []bool, count int, rand *rand.Rand) {
+	for _, node := range chooseRandom(markedNodes(candidates), count, rand) {
+		disease.SetState(node, disease.model().Seed)
+	}
+}
+
+// chooseRandom shuffles up to count random nodes to the front of nodes and returns them.
+// A partial Fisher-Yates shuffle only uses random numbers for the nodes that are chosen.
+func chooseRandom(nodes []int, count int, rand *rand.Rand) []int {
+	if count > len(nodes) {
+		count = len(nodes)
+	}
+	for i := 0; i < count; i++ {
+		j := i + rand.Intn(len(nodes)-i)
+		nodes[i], nodes[j] = nodes[j], nodes[i]
+	}
+	return nodes[:count]
+}
@@ -14,3 +14,110 @@ func TestInfectN(t *testing.T) {
 		}
 	}
 }
+
+// infectedBy makes a network from adjMat with a disease that can't spread and is infected with
+// strategy. Infected nodes stay infected for 100 steps.
+func infectedBy(t *testing.T, adjMat Network, strategy InitialInfectionStrategy) (DiseasedNetwork, Disease) {
+	disease, err := NewCompartmentalDisease(SIRModel(NewFixedDuration(100)), 0, strategy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewDiseasedNetwork(&adjMat, []Disease{disease}, nil, []Observer{}, 6), disease
+}
+
+// makePath makes a network where each node is connected to the next one
+func makePath(numNodes int) Network {
+	adjMat := NewNetwork(numNodes)
+	for node := 0; node < numNodes-1; node++ {
+		adjMat.AddEdge(node, node+1, 1)
+	}
+	return adjMat
+}
+
+func TestInfectNMoreThanNodes(t *testing.T) {
+	_, disease := infectedBy(t, makePath(10), NewInfectN(20))
+	if disease.NumInState(StateI) != 10 {
+		t.Errorf("Expected every node to be infected, found %d", disease.NumInState(StateI))
+	}
+}
+
+func TestInfectFraction(t *testing.T) {
+	_, disease := infectedBy(t, makePath(1000), NewInfectFraction(.25))
+	if disease.NumInState(StateI) != 250 {
+		t.Errorf("Expected 250 infected, found %d", disease.NumInState(StateI))
+	}
+}
+
+func TestInfectNodes(t *testing.T) {
+	_, disease := infectedBy(t, makePath(10), NewInfectNodes(3, 5))
+	if disease.NumInState(StateI) != 2 || disease.State(3) != StateI || disease.State(5) != StateI {
+		t.Errorf("Expected only nodes 3 and 5 to be infected, found %v", disease.FindNodesInState(StateI))
+	}
+	if NewInfectNodes(3, 5).Validate(6) != nil || NewInfectNodes(3, 5).Validate(5) == nil ||
+		NewInfectNodes(-1).Validate(6) == nil {
+		t.Error("Expected only nodes in the network to be valid")
+	}
+}
+
+func TestInfectHighestDegree(t *testing.T) {
+	adjMat := makePath(10)
+	adjMat.AddEdge(6, 0, 1)
+	adjMat.AddEdge(6, 2, 1)
+	adjMat.AddEdge(4, 9, 1)
+	_, disease := infectedBy(t, adjMat, NewInfectHighestDegree(2))
+	// node 6 has 4 neighbors and nodes 2 and 4 have 3, so node 2 wins the tie for second
+	if disease.NumInState(StateI) != 2 || disease.State(6) != StateI || disease.State(2) != StateI {
+		t.Errorf("Expected nodes 6 and 2 to be infected, found %v", disease.FindNodesInState(StateI))
+	}
+}
+
+func TestInfectCluster(t *testing.T) {
+	_, disease := infectedBy(t, makePath(50), NewInfectCluster(5))
+	infected := disease.FindNodesInState(StateI)
+	lowest, highest := 50, -1
+	for node := range infected {
+		if node < lowest {
+			lowest = node
+		}
+		if node > highest {
+			highest = node
+		}
+	}
+	if len(infected) != 5 || highest-lowest != 4 {
+		t.Errorf("Expected 5 infected nodes in a row, found %v", infected)
+	}
+}
+
+func TestInfectGroup(t *testing.T) {
+	adjMat := makePath(100)
+	attributes := DefaultAttributes(100)
+	for node := 0; node < 100; node += 2 {
+		attributes[node].Group = 1
+	}
+	adjMat.SetNodeAttributes(attributes)
+	_, disease := infectedBy(t, adjMat, NewInfectGroup(1, 10))
+	infected := disease.FindNodesInState(StateI)
+	if len(infected) != 10 {
+		t.Errorf("Expected 10 infected, found %d", len(infected))
+	}
+	for node := range infected {
+		if node%2 != 0 {
+			t.Errorf("Node %d isn't in group 1 but was infected", node)
+		}
+	}
+}
+
+func TestStaggeredInfection(t *testing.T) {
+	net, disease := infectedBy(t, makePath(100), NewStaggeredInfection(3, 2, 3))
+	expected := []int{3, 6, 6, 9, 9, 9, 9}
+	if disease.NumInState(StateI) != 3 {
+		t.Errorf("Expected 3 infected at the start, found %d", disease.NumInState(StateI))
+	}
+	for step, numInfected := range expected {
+		net.Step()
+		if disease.NumInState(StateI) != numInfected {
+			t.Errorf("Expected %d infected after step %d, found %d", numInfected, step+1,
+				disease.NumInState(StateI))
+		}
+	}
+}
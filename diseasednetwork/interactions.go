@@ -0,0 +1,132 @@
+package diseasednetwork
+
+import (
+	"fmt"
+
+	"github.com/GaudiestTooth17/infection-resistant-network/dynamicnet"
+)
+
+// Interaction changes how susceptible nodes are to one disease while they are in some of the
+// compartments of another. Diseases are referred to by their index in the DiseasedNetwork.
+type Interaction struct {
+	// From is the disease whose compartments matter
+	From int
+	// To is the disease whose chance of infecting the node changes
+	To int
+	// Compartments are the compartments of From that the interaction applies in.
+	// nil means the infected compartments of From.
+	Compartments []uint8
+	// Susceptibility multiplies the chance that a node in one of the compartments is infected by To
+	Susceptibility float32
+}
+
+// NewCrossImmunity makes nodes that have recovered from the disease at index from less likely to
+// catch the disease at index to. A protection of 1 is complete immunity.
+// Recovered nodes are the ones in StateR, so from must use the layout of the built in models.
+func NewCrossImmunity(from, to int, protection float32) Interaction {
+	return Interaction{From: from, To: to, Compartments: []uint8{StateR}, Susceptibility: 1 - protection}
+}
+
+// NewCoinfection makes nodes that are infected with the disease at index from boost times as
+// likely to catch the disease at index to
+func NewCoinfection(from, to int, boost float32) Interaction {
+	return Interaction{From: from, To: to, Susceptibility: boost}
+}
+
+// NewAwareness makes nodes that are infected with an information spreading good disease less
+// likely to catch a bad disease. A reduction of 1 means aware nodes can't be infected.
+// Use SetAwareBehavior to have aware nodes rewire the network differently as well.
+func NewAwareness(goodDisease, badDisease int, reduction float32) Interaction {
+	return Interaction{From: goodDisease, To: badDisease, Susceptibility: 1 - reduction}
+}
+
+// compiledInteraction is an Interaction with a lookup table for the compartments it applies in
+type compiledInteraction struct {
+	from, to       int
+	applies        []bool
+	susceptibility float32
+}
+
+// SetInteractions replaces the ways the diseases affect each other. Without any interactions the
+// diseases spread independently. With the GillespieEngine each disease sees the others as they
+// were when it started its part of the step. It panics if an interaction refers to a disease or
+// compartment that doesn't exist or has a negative susceptibility.
+func (n *DiseasedNetwork) SetInteractions(interactions []Interaction) {
+	n.interactions = make([]compiledInteraction, len(interactions))
+	n.maxInteraction = make([]float32, len(n.diseases))
+	for i := range n.maxInteraction {
+		n.maxInteraction[i] = 1
+	}
+	for i, interaction := range interactions {
+		if interaction.From < 0 || interaction.From >= len(n.diseases) ||
+			interaction.To < 0 || interaction.To >= len(n.diseases) {
+			panic(fmt.Sprintf("Interaction %d refers to a disease that doesn't exist!", i))
+		}
+		if interaction.Susceptibility < 0 {
+			panic(fmt.Sprintf("Interaction %d has a negative susceptibility!", i))
+		}
+		model := n.diseases[interaction.From].model()
+		applies := model.infected
+		if interaction.Compartments != nil {
+			applies = make([]bool, len(model.Compartments))
+			for _, compartment := range interaction.Compartments {
+				if int(compartment) >= len(applies) {
+					panic(fmt.Sprintf("Interaction %d refers to a compartment that doesn't exist!", i))
+				}
+				applies[compartment] = true
+			}
+		}
+		n.interactions[i] = compiledInteraction{
+			from:           interaction.From,
+			to:             interaction.To,
+			applies:        applies,
+			susceptibility: interaction.Susceptibility,
+		}
+		if interaction.Susceptibility > 1 {
+			n.maxInteraction[interaction.To] *= interaction.Susceptibility
+		}
+	}
+}
+
+// interactionMultiplier is how much the other diseases multiply the chance of node being infected
+// by the disease at diseaseIndex
+func (n *DiseasedNetwork) interactionMultiplier(diseaseIndex, node int) float32 {
+	multiplier := float32(1)
+	for _, interaction := range n.interactions {
+		if interaction.to == diseaseIndex && interaction.applies[n.diseases[interaction.from].State(node)] {
+			multiplier *= interaction.susceptibility
+		}
+	}
+	return multiplier
+}
+
+// maxInteractionMultiplier is the most interactionMultiplier can be for the disease at diseaseIndex
+func (n *DiseasedNetwork) maxInteractionMultiplier(diseaseIndex int) float32 {
+	if n.maxInteraction == nil {
+		return 1
+	}
+	return n.maxInteraction[diseaseIndex]
+}
+
+// SetAwareBehavior makes the nodes that are in the infected compartments of the disease at
+// diseaseIndex, usually an information spreading good disease, follow behavior instead of the
+// network's behavior. This lets awareness of the bad disease trigger protective rewiring even
+// in a network whose behavior is nil. A nil behavior turns it off.
+func (n *DiseasedNetwork) SetAwareBehavior(diseaseIndex int, behavior dynamicnet.AgentBehavior) {
+	if diseaseIndex < 0 || diseaseIndex >= len(n.diseases) {
+		panic("Awareness can't come from a disease that doesn't exist!")
+	}
+	n.awareDisease = diseaseIndex
+	n.awareBehavior = behavior
+}
+
+// behaviorOf returns the behavior node follows or nil if it doesn't rewire
+func (n *DiseasedNetwork) behaviorOf(node int) dynamicnet.AgentBehavior {
+	if n.awareBehavior != nil {
+		disease := n.diseases[n.awareDisease]
+		if disease.model().infected[disease.State(node)] {
+			return n.awareBehavior
+		}
+	}
+	return n.behavior
+}
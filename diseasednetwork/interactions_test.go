@@ -0,0 +1,118 @@
+package diseasednetwork
+
+import (
+	"math"
+	"testing"
+
+	"github.com/GaudiestTooth17/infection-resistant-network/dynamicnet"
+)
+
+// makeInteractingPairs makes isolated pairs of nodes where the even node in each pair is infected
+// with the bad disease. The odd node in every other pair is put in otherState of a second disease
+// that can't spread. The bad disease has an infection probability of .2.
+func makeInteractingPairs(t *testing.T, numPairs int, timeToR int16, otherState uint8,
+	engine Engine) DiseasedNetwork {
+	adjMat := NewNetwork(2 * numPairs)
+	for pair := 0; pair < numPairs; pair++ {
+		adjMat.AddEdge(2*pair, 2*pair+1, 1)
+	}
+	bad, err := NewCompartmentalDisease(SIRModel(NewFixedDuration(timeToR)), .2, NewInfectN(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := NewCompartmentalDisease(SIRModel(NewFixedDuration(1000)), 0, NewInfectN(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	net := NewDiseasedNetwork(&adjMat, []Disease{bad, other}, nil, []Observer{}, 8)
+	net.SetEngine(engine)
+	for pair := 0; pair < numPairs; pair++ {
+		bad.SetState(2*pair, StateI)
+		if pair%2 == 1 {
+			other.SetState(2*pair+1, otherState)
+		}
+	}
+	return net
+}
+
+func TestInteractions(t *testing.T) {
+	tests := []struct {
+		name        string
+		interaction Interaction
+		otherState  uint8
+		expected    float64
+	}{
+		{"cross immunity", NewCrossImmunity(1, 0, 1), StateR, 0},
+		{"partial cross immunity", NewCrossImmunity(1, 0, .5), StateR, .1},
+		{"coinfection", NewCoinfection(1, 0, 2.5), StateI, .5},
+		{"awareness", NewAwareness(1, 0, .5), StateI, .1},
+	}
+	for _, test := range tests {
+		net := makeInteractingPairs(t, 4000, 0, test.otherState, DiscreteEngine)
+		net.SetInteractions([]Interaction{test.interaction})
+		net.Step()
+		unaffected, affected := transmittedFraction(&net)
+		if math.Abs(unaffected-.2) > .03 || math.Abs(affected-test.expected) > .03 {
+			t.Errorf("%s: expected .2 of the unaffected and %f of the affected pairs to transmit, found %f and %f",
+				test.name, test.expected, unaffected, affected)
+		}
+	}
+}
+
+// TestGillespieCoinfection makes sure the bound on the transmission rate accounts for interactions
+func TestGillespieCoinfection(t *testing.T) {
+	timeToR := 4.0
+	net := makeInteractingPairs(t, 4000, int16(timeToR), StateI, GillespieEngine)
+	net.SetInteractions([]Interaction{NewCoinfection(1, 0, 2.5)})
+	for step := 0; step < 100; step++ {
+		net.Step()
+	}
+	_, affected := transmittedFraction(&net)
+	beta := transmissionRate(.5)
+	expected := beta / (beta + 1/timeToR)
+	if math.Abs(affected-expected) > .03 {
+		t.Errorf("Expected %f of the coinfected pairs to transmit, found %f", expected, affected)
+	}
+}
+
+// TestAwareBehavior makes only the aware leaves of a star cut their ties to the infected center
+func TestAwareBehavior(t *testing.T) {
+	adjMat := NewNetwork(21)
+	for node := 1; node < 21; node++ {
+		adjMat.AddEdge(0, node, 1)
+	}
+	bad := NewBasicDisease(0, 100, 0, NewInfectNodes(0))
+	good := NewGoodDisease(100, 100, 0, NewInfectN(0))
+	net := NewDiseasedNetwork(&adjMat, []Disease{bad, good}, nil, []Observer{}, 1)
+	for node := 1; node <= 10; node++ {
+		good.SetState(node, StateI)
+	}
+	net.SetAwareBehavior(1, dynamicnet.NewSimpleBehavior(0, 20, 1, 0))
+	net.Step()
+	for node := 1; node < 21; node++ {
+		aware := node <= 10
+		if net.adjMat.HasEdge(0, node) == aware {
+			t.Errorf("Node %d is aware: %t, but still connected to the infected node: %t",
+				node, aware, net.adjMat.HasEdge(0, node))
+		}
+	}
+}
+
+func TestInvalidInteractions(t *testing.T) {
+	interactions := map[string]Interaction{
+		"missing disease":     NewCoinfection(2, 0, 2),
+		"missing compartment": {From: 1, To: 0, Compartments: []uint8{9}, Susceptibility: 1},
+		"negative":            NewCrossImmunity(1, 0, 2),
+	}
+	for name, interaction := range interactions {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Expected an interaction with a %s to panic", name)
+				}
+			}()
+			net := makeInteractingPairs(t, 2, 0, StateR, DiscreteEngine)
+			net.SetInteractions([]Interaction{interaction})
+		}()
+	}
+}
@@ -0,0 +1,326 @@
+package diseasednetwork
+
+import "sort"
+
+// Intervention vaccinates the nodes chosen by a Targeting strategy against the bad disease (slot 0).
+// Each node can only be given one dose, and only nodes in a susceptible compartment are given doses.
+// Vaccines are all or nothing: a dose moves the node to the disease's Immune compartment with
+// probability efficacy and has no effect otherwise.
+type Intervention struct {
+	targeting    Targeting
+	budget       int
+	efficacy     float64
+	start        uint
+	dosesPerStep int
+}
+
+// NewIntervention returns an Intervention that gives out up to budget doses at the start of the
+// first step, before the disease has had a chance to spread. Use Schedule to spread them out.
+func NewIntervention(targeting Targeting, budget int, efficacy float64) Intervention {
+	if budget < 0 {
+		panic("An intervention can't have a negative budget!")
+	}
+	if efficacy < 0 || efficacy > 1 {
+		panic("Vaccine efficacy must be between 0 and 1!")
+	}
+	return Intervention{targeting: targeting, budget: budget, efficacy: efficacy, start: 1}
+}
+
+// Schedule returns a copy of i that starts giving out doses in step start and gives out at most
+// dosesPerStep doses in each step after that. Step 1 is the first call to DiseasedNetwork.Step.
+// A dosesPerStep of 0 gives out as much of the budget as there are targets for.
+// Doses that can't be given out in one step because there aren't enough targets are saved
+// for later steps.
+func (i Intervention) Schedule(start uint, dosesPerStep int) Intervention {
+	if dosesPerStep < 0 {
+		panic("An intervention can't give out a negative number of doses!")
+	}
+	i.start = start
+	i.dosesPerStep = dosesPerStep
+	return i
+}
+
+// SetInterventions replaces the network's interventions. They are applied in order at the start
+// of every step, before the agents rewire the network. Passing nil removes them, but nodes that
+// have already been vaccinated stay that way.
+func (n *DiseasedNetwork) SetInterventions(interventions []Intervention) {
+	n.interventions = interventions
+	n.dosesGiven = make([]int, len(interventions))
+	if n.vaccinated == nil {
+		n.vaccinated = make([]bool, n.NumNodes())
+		n.immunized = make([]bool, n.NumNodes())
+	}
+}
+
+// intervene lets each intervention give out its doses for the step
+func (n *DiseasedNetwork) intervene() {
+	step := n.stepNum + 1
+	for i, intervention := range n.interventions {
+		count := intervention.budget - n.dosesGiven[i]
+		if step < intervention.start || count == 0 {
+			continue
+		}
+		if intervention.dosesPerStep > 0 && intervention.dosesPerStep < count {
+			count = intervention.dosesPerStep
+		}
+		for _, node := range intervention.targeting.targets(n, n.eligibleForVaccine(), count) {
+			n.vaccinate(node, intervention.efficacy)
+			n.dosesGiven[i]++
+		}
+	}
+}
+
+// eligibleForVaccine marks the nodes that haven't had a dose and are susceptible to the bad disease
+func (n *DiseasedNetwork) eligibleForVaccine() []bool {
+	disease := n.diseases[0]
+	susceptible := disease.model().susceptible
+	eligible := make([]bool, n.NumNodes())
+	for node := range eligible {
+		eligible[node] = !n.vaccinated[node] && susceptible[disease.State(node)]
+	}
+	return eligible
+}
+
+// vaccinate gives node a dose that works with probability efficacy. Random numbers are only
+// used if efficacy is less than 1. The dose does nothing if the bad disease's Immune compartment
+// is a susceptible one.
+func (n *DiseasedNetwork) vaccinate(node int, efficacy float64) {
+	n.vaccinated[node] = true
+	disease := n.diseases[0]
+	model := disease.model()
+	if model.susceptible[model.Immune] {
+		return
+	}
+	if efficacy < 1 && n.rand.Float64() >= efficacy {
+		return
+	}
+	disease.SetState(node, model.Immune)
+	n.immunized[node] = true
+}
+
+// IsImmunized reports whether node was given a dose that worked and is still in the bad disease's
+// Immune compartment
+func (n *DiseasedNetwork) IsImmunized(node int) bool {
+	disease := n.diseases[0]
+	return n.immunized != nil && n.immunized[node] && disease.State(node) == disease.model().Immune
+}
+
+// NumImmunized returns the number of nodes that IsImmunized is true for
+func (n *DiseasedNetwork) NumImmunized() int {
+	total := 0
+	for node := range n.immunized {
+		if n.IsImmunized(node) {
+			total++
+		}
+	}
+	return total
+}
+
+// Targeting chooses which nodes an Intervention vaccinates
+type Targeting interface {
+	// targets returns up to count of the nodes marked in eligible, most important first.
+	// It should get all of its randomness from n.rand so that simulations can be replayed.
+	targets(n *DiseasedNetwork, eligible []bool, count int) []int
+}
+
+// RandomTargeting vaccinates nodes chosen uniformly at random
+type RandomTargeting struct{}
+
+// NewRandomTargeting returns an instance of the RandomTargeting strategy
+func NewRandomTargeting() RandomTargeting {
+	return RandomTargeting{}
+}
+
+func (RandomTargeting) targets(n *DiseasedNetwork, eligible []bool, count int) []int {
+	return chooseRandom(markedNodes(eligible), count, n.rand)
+}
+
+// DegreeTargeting vaccinates the nodes with the most neighbors first. Degrees are measured when
+// the doses are given out, so rewiring can change who is targeted.
+type DegreeTargeting struct{}
+
+// NewDegreeTargeting returns an instance of the DegreeTargeting strategy
+func NewDegreeTargeting() DegreeTargeting {
+	return DegreeTargeting{}
+}
+
+func (DegreeTargeting) targets(n *DiseasedNetwork, eligible []bool, count int) []int {
+	return highestScores(eligible, count, func(node int) float64 {
+		return float64(n.Degree(node))
+	})
+}
+
+// AcquaintanceTargeting vaccinates a random neighbor of a random node. This finds nodes with
+// many neighbors without knowing the structure of the network.
+type AcquaintanceTargeting struct{}
+
+// NewAcquaintanceTargeting returns an instance of the AcquaintanceTargeting strategy
+func NewAcquaintanceTargeting() AcquaintanceTargeting {
+	return AcquaintanceTargeting{}
+}
+
+// targets gives up after a number of misses proportional to the size of the network, so it can
+// return fewer than count nodes even if more are eligible
+func (AcquaintanceTargeting) targets(n *DiseasedNetwork, eligible []bool, count int) []int {
+	chosen := make([]int, 0, count)
+	taken := make(map[int]bool)
+	for misses := 0; len(chosen) < count && misses < 10*n.NumNodes(); {
+		neighbors := n.adjMat.Neighbors(n.rand.Intn(n.NumNodes()))
+		if len(neighbors) == 0 {
+			misses++
+			continue
+		}
+		acquaintance := int(neighbors[n.rand.Intn(len(neighbors))])
+		if !eligible[acquaintance] || taken[acquaintance] {
+			misses++
+			continue
+		}
+		taken[acquaintance] = true
+		chosen = append(chosen, acquaintance)
+	}
+	return chosen
+}
+
+// BetweennessTargeting vaccinates the nodes that the most shortest paths pass through first.
+// Betweenness is recalculated every time doses are given out, which takes
+// O(nodes * edges) time, so it is best suited to campaigns that give out all their doses at once.
+type BetweennessTargeting struct{}
+
+// NewBetweennessTargeting returns an instance of the BetweennessTargeting strategy
+func NewBetweennessTargeting() BetweennessTargeting {
+	return BetweennessTargeting{}
+}
+
+func (BetweennessTargeting) targets(n *DiseasedNetwork, eligible []bool, count int) []int {
+	centrality := betweenness(&n.adjMat)
+	return highestScores(eligible, count, func(node int) float64 {
+		return centrality[node]
+	})
+}
+
+// RingTargeting vaccinates the nodes around detected cases. A case is detected once its node is
+// infectious, and the rings around the newest cases are vaccinated first. radius is how many
+// edges away from a case a node can be and still be part of its ring.
+type RingTargeting struct {
+	radius int
+}
+
+// NewRingTargeting returns an instance of the RingTargeting strategy
+func NewRingTargeting(radius int) RingTargeting {
+	if radius < 1 {
+		panic("A ring must have a radius of at least 1!")
+	}
+	return RingTargeting{radius: radius}
+}
+
+func (r RingTargeting) targets(n *DiseasedNetwork, eligible []bool, count int) []int {
+	disease := n.diseases[0]
+	infectious := disease.model().infectious
+	cases := disease.InfectionTree().Cases()
+	chosen := make([]int, 0, count)
+	taken := make(map[int]bool)
+	for i := len(cases) - 1; i >= 0 && len(chosen) < count; i-- {
+		if cases[i].Resolved || !infectious[disease.State(cases[i].Node)] {
+			continue
+		}
+		for _, node := range n.ring(cases[i].Node, r.radius) {
+			if len(chosen) == count {
+				break
+			}
+			if eligible[node] && !taken[node] {
+				taken[node] = true
+				chosen = append(chosen, node)
+			}
+		}
+	}
+	return chosen
+}
+
+// ring returns the nodes at most radius edges away from center, closest first
+func (n *DiseasedNetwork) ring(center, radius int) []int {
+	distance := map[int]int{center: 0}
+	queue := []int{center}
+	for head := 0; head < len(queue); head++ {
+		node := queue[head]
+		if distance[node] == radius {
+			continue
+		}
+		for _, neighbor := range n.adjMat.Neighbors(node) {
+			if _, seen := distance[int(neighbor)]; !seen {
+				distance[int(neighbor)] = distance[node] + 1
+				queue = append(queue, int(neighbor))
+			}
+		}
+	}
+	return queue[1:]
+}
+
+// markedNodes lists the nodes that are marked in ascending order
+func markedNodes(marked []bool) []int {
+	nodes := make([]int, 0)
+	for node, isMarked := range marked {
+		if isMarked {
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes
+}
+
+// highestScores returns up to count of the nodes marked in eligible with the highest scores.
+// Ties go to the node with the lower index.
+func highestScores(eligible []bool, count int, score func(node int) float64) []int {
+	candidates := markedNodes(eligible)
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return score(candidates[i]) > score(candidates[j])
+	})
+	if count < len(candidates) {
+		candidates = candidates[:count]
+	}
+	return candidates
+}
+
+// betweenness returns the betweenness centrality of every node using Brandes' algorithm.
+// Edge weights are ignored and each path is counted from both of its ends.
+func betweenness(adjMat *Network) []float64 {
+	numNodes := adjMat.NumNodes()
+	centrality := make([]float64, numNodes)
+	numPaths := make([]float64, numNodes)
+	distance := make([]int, numNodes)
+	dependency := make([]float64, numNodes)
+	predecessors := make([][]int32, numNodes)
+	queue := make([]int32, 0, numNodes)
+	for source := 0; source < numNodes; source++ {
+		for node := range distance {
+			distance[node] = -1
+			numPaths[node] = 0
+			dependency[node] = 0
+			predecessors[node] = predecessors[node][:0]
+		}
+		distance[source] = 0
+		numPaths[source] = 1
+		queue = append(queue[:0], int32(source))
+		for head := 0; head < len(queue); head++ {
+			node := queue[head]
+			for _, neighbor := range adjMat.Neighbors(int(node)) {
+				if distance[neighbor] < 0 {
+					distance[neighbor] = distance[node] + 1
+					queue = append(queue, neighbor)
+				}
+				if distance[neighbor] == distance[node]+1 {
+					numPaths[neighbor] += numPaths[node]
+					predecessors[neighbor] = append(predecessors[neighbor], node)
+				}
+			}
+		}
+		// the queue holds the nodes in order of distance, so it is walked backwards to
+		// add up the dependencies
+		for i := len(queue) - 1; i > 0; i-- {
+			node := queue[i]
+			for _, predecessor := range predecessors[node] {
+				dependency[predecessor] += numPaths[predecessor] / numPaths[node] * (1 + dependency[node])
+			}
+			centrality[node] += dependency[node]
+		}
+	}
+	return centrality
+}
@@ -0,0 +1,151 @@
+package diseasednetwork
+
+import (
+	"math"
+	"testing"
+)
+
+// makeStar makes a network where node 0 is connected to every other node. The disease can't spread.
+func makeStar(t *testing.T, numNodes int) DiseasedNetwork {
+	adjMat := NewNetwork(numNodes)
+	for node := 1; node < numNodes; node++ {
+		adjMat.AddEdge(0, node, 1)
+	}
+	disease, err := NewCompartmentalDisease(SIRModel(NewFixedDuration(5)), 0, NewInfectN(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewDiseasedNetwork(&adjMat, []Disease{disease}, nil, []Observer{}, 3)
+}
+
+func TestVaccinationBudget(t *testing.T) {
+	net := makeStar(t, 4000)
+	net.SetInterventions([]Intervention{NewIntervention(NewRandomTargeting(), 30, 1)})
+	for step := 0; step < 3; step++ {
+		net.Step()
+	}
+	if net.NumImmunized() != 30 || net.NumInState(StateR, 0) != 30 {
+		t.Errorf("Expected 30 nodes to be immunized, found %d with %d in R",
+			net.NumImmunized(), net.NumInState(StateR, 0))
+	}
+
+	net = makeStar(t, 4000)
+	net.SetInterventions([]Intervention{NewIntervention(NewRandomTargeting(), 2000, .5)})
+	net.Step()
+	if math.Abs(float64(net.NumImmunized())-1000) > 70 {
+		t.Errorf("Expected about half of the 2000 doses to work, found %d", net.NumImmunized())
+	}
+}
+
+func TestVaccinationSchedule(t *testing.T) {
+	net := makeStar(t, 100)
+	net.SetInterventions([]Intervention{NewIntervention(NewDegreeTargeting(), 12, 1).Schedule(3, 5)})
+	expected := []int{0, 0, 5, 10, 12, 12}
+	for step, numImmunized := range expected {
+		net.Step()
+		if net.NumImmunized() != numImmunized {
+			t.Errorf("Expected %d nodes to be immunized after step %d, found %d",
+				numImmunized, step+1, net.NumImmunized())
+		}
+	}
+	if !net.IsImmunized(0) {
+		t.Error("Expected the center of the star to be vaccinated first")
+	}
+}
+
+func TestVaccineOnlyGivenToSusceptible(t *testing.T) {
+	net := makeStar(t, 10)
+	for node := 0; node < 8; node++ {
+		net.diseases[0].SetState(node, StateI)
+	}
+	net.SetInterventions([]Intervention{NewIntervention(NewRandomTargeting(), 5, 1)})
+	net.Step()
+	if net.NumImmunized() != 2 || net.dosesGiven[0] != 2 {
+		t.Errorf("Expected only the 2 susceptible nodes to get doses, found %d immunized with %d doses",
+			net.NumImmunized(), net.dosesGiven[0])
+	}
+}
+
+// TestVaccineWithoutImmuneCompartment makes sure doses do nothing when the model leaves Immune
+// at a susceptible compartment
+func TestVaccineWithoutImmuneCompartment(t *testing.T) {
+	model := CompartmentalModel{
+		Compartments: []Compartment{
+			{Name: "S", Susceptible: true},
+			{Name: "I", Infected: true, Infectious: true, Duration: NewFixedDuration(5), Next: moveTo(0)},
+		},
+		Exposure: 1,
+		Seed:     1,
+	}
+	disease, err := NewCompartmentalDisease(model, 0, NewInfectN(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	adjMat := NewNetwork(10)
+	net := NewDiseasedNetwork(&adjMat, []Disease{disease}, nil, []Observer{}, 3)
+	net.SetInterventions([]Intervention{NewIntervention(NewRandomTargeting(), 5, 1)})
+	net.Step()
+	if net.NumImmunized() != 0 || net.NumInState(0, 0) != 10 {
+		t.Errorf("Expected the doses to do nothing, found %d immunized and %d susceptible",
+			net.NumImmunized(), net.NumInState(0, 0))
+	}
+}
+
+func TestAcquaintanceTargeting(t *testing.T) {
+	net := makeStar(t, 21)
+	net.SetInterventions([]Intervention{NewIntervention(NewAcquaintanceTargeting(), 2, 1)})
+	net.Step()
+	if !net.IsImmunized(0) || net.NumImmunized() != 2 {
+		t.Errorf("Expected the center and one other node to be vaccinated, found %d including the center: %t",
+			net.NumImmunized(), net.IsImmunized(0))
+	}
+}
+
+func TestBetweenness(t *testing.T) {
+	// 0 - 1 - 2 - 3 - 4 with 5 hanging off of 2
+	adjMat := NewNetwork(6)
+	for _, edge := range [][2]int{{0, 1}, {1, 2}, {2, 3}, {3, 4}, {2, 5}} {
+		adjMat.AddEdge(edge[0], edge[1], 1)
+	}
+	expected := []float64{0, 8, 16, 8, 0, 0}
+	for node, centrality := range betweenness(&adjMat) {
+		if math.Abs(centrality-expected[node]) > 1e-9 {
+			t.Errorf("Expected node %d to have a betweenness of %f, found %f", node, expected[node], centrality)
+		}
+	}
+
+	disease := NewBasicDisease(1, 1, 0, NewInfectN(0))
+	net := NewDiseasedNetwork(&adjMat, []Disease{disease}, nil, []Observer{}, 1)
+	net.SetInterventions(nil)
+	chosen := NewBetweennessTargeting().targets(&net, net.eligibleForVaccine(), 3)
+	if len(chosen) != 3 || chosen[0] != 2 || chosen[1] != 1 || chosen[2] != 3 {
+		t.Errorf("Expected nodes 2, 1 and 3 to be chosen, found %v", chosen)
+	}
+}
+
+// TestRingVaccination stops a disease that always spreads by vaccinating around the first case
+func TestRingVaccination(t *testing.T) {
+	adjMat := NewNetwork(9)
+	for node := 0; node < 8; node++ {
+		adjMat.AddEdge(node, node+1, 1)
+	}
+	disease, err := NewCompartmentalDisease(SIRModel(NewFixedDuration(3)), 1, NewInfectN(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	net := NewDiseasedNetwork(&adjMat, []Disease{disease}, nil, []Observer{}, 1)
+	disease.SetState(4, StateI)
+	net.SetInterventions([]Intervention{NewIntervention(NewRingTargeting(2), 9, 1)})
+	for step := 0; step < 5; step++ {
+		net.Step()
+	}
+	for _, node := range []int{2, 3, 5, 6} {
+		if !net.IsImmunized(node) {
+			t.Errorf("Expected node %d to be in the ring", node)
+		}
+	}
+	if net.NumImmunized() != 4 || net.NumInState(StateS, 0) != 4 {
+		t.Errorf("Expected 4 nodes to be vaccinated and 4 to be untouched, found %d and %d",
+			net.NumImmunized(), net.NumInState(StateS, 0))
+	}
+}
@@ -1,54 +1,220 @@
 package diseasednetwork
 
-// Network represents an undirected graph/network
+import "sort"
+
+// Network represents an undirected graph/network.
+// The adjacency lists are stored in compressed sparse row (CSR) form: the neighbors of node are
+// targets[start[node]:start[node]+degree[node]] in ascending order, and weights runs parallel to
+// targets. Each row may have unused capacity after it so that edges can be added without shifting
+// every other row. A row that runs out of room is moved to the end of targets.
+//
+// Don't copy a Network with assignment and then change either one. A plain copy shares its rows
+// with the original, so adding or removing an edge in one can leave the other with the wrong
+// neighbors, or panicking when a moved row is past the end of its targets. Use MakeCopy to get a
+// network that can be changed on its own, and pass *Network to code that changes it. Plain copies
+// are fine for reading a network that no longer changes.
 type Network struct {
-	// entries in neighbors are stored with the smaller node coming first, then the larger one
-	neighbors map[int]map[int]uint8
+	start    []int32
+	degree   []int32
+	capacity []int32
+	targets  []int32
+	weights  []uint8
+	// abandoned counts the slots in targets left behind by rows that were moved
+	abandoned int
+	// maxWeight is at least as large as every weight in the network. It doesn't go down when
+	// edges are removed.
+	maxWeight uint8
+	// attributes holds the attributes of each node or nil if they are all the same.
+	// It is shared by copies of the network.
+	attributes []NodeAttributes
 }
 
-// NewNetwork returns a Network with the given number of nodes
+// minRowCapacity is the capacity given to a row the first time it runs out of room
+const minRowCapacity = 4
+
+// NewNetwork returns a Network with the given number of nodes.
+// See Network for why it shouldn't be copied with assignment.
 func NewNetwork(numNodes int) Network {
-	neighbors := make(map[int]map[int]uint8)
-	for i := 0; i < numNodes; i++ {
-		neighbors[i] = make(map[int]uint8)
+	return Network{
+		start:    make([]int32, numNodes),
+		degree:   make([]int32, numNodes),
+		capacity: make([]int32, numNodes),
 	}
-	return Network{neighbors: neighbors}
 }
 
-// MakeCopy copies the network so that different memory is used for the internal node structure
+// MakeCopy copies the network so that different memory is used for the internal node structure.
+// The copy and the original can be changed independently, except that they share the node
+// attributes. The copy has no unused capacity.
 func (n *Network) MakeCopy() Network {
-	neighborsCopy := make(map[int]map[int]uint8)
-	for node, adjacentNodes := range n.neighbors {
-		neighborsCopy[node] = make(map[int]uint8)
-		for adjacentNode, weight := range adjacentNodes {
-			neighborsCopy[node][adjacentNode] = weight
-		}
+	numNodes := n.NumNodes()
+	numEntries := 0
+	for _, degree := range n.degree {
+		numEntries += int(degree)
+	}
+	network := Network{
+		start:      make([]int32, numNodes),
+		degree:     make([]int32, numNodes),
+		capacity:   make([]int32, numNodes),
+		targets:    make([]int32, 0, numEntries),
+		weights:    make([]uint8, 0, numEntries),
+		maxWeight:  n.maxWeight,
+		attributes: n.attributes,
 	}
-	return Network{neighbors: neighborsCopy}
+	copy(network.degree, n.degree)
+	copy(network.capacity, n.degree)
+	for node := 0; node < numNodes; node++ {
+		network.start[node] = int32(len(network.targets))
+		network.targets = append(network.targets, n.Neighbors(node)...)
+		network.weights = append(network.weights, n.NeighborWeights(node)...)
+	}
+	return network
 }
 
-// NeighborsOf returns the neighbors of the given node
+// NeighborsOf returns the neighbors of the given node mapped to the weights of the edges to them.
+// The map is built on every call, so Neighbors and NeighborWeights should be preferred in hot loops.
 func (n Network) NeighborsOf(node int) map[int]uint8 {
-	return n.neighbors[node]
+	neighbors := make(map[int]uint8, n.degree[node])
+	weights := n.NeighborWeights(node)
+	for i, neighbor := range n.Neighbors(node) {
+		neighbors[int(neighbor)] = weights[i]
+	}
+	return neighbors
+}
+
+// Neighbors returns the neighbors of the given node in ascending order.
+// The slice belongs to the network. It must not be modified and is only valid until
+// the network is changed.
+func (n Network) Neighbors(node int) []int32 {
+	s := n.start[node]
+	d := n.degree[node]
+	return n.targets[s : s+d : s+d]
+}
+
+// NeighborWeights returns the weights of the edges to the nodes returned by Neighbors.
+// The same rules about ownership apply.
+func (n Network) NeighborWeights(node int) []uint8 {
+	s := n.start[node]
+	d := n.degree[node]
+	return n.weights[s : s+d : s+d]
+}
+
+// Degree returns the number of neighbors of the given node
+func (n Network) Degree(node int) int {
+	return int(n.degree[node])
 }
 
 // NumNodes returns the number of nodes in the network
 func (n Network) NumNodes() int {
-	return len(n.neighbors)
+	return len(n.degree)
+}
+
+// HasEdge reports whether there is an edge between node1 and node2
+func (n Network) HasEdge(node1, node2 int) bool {
+	_, found := n.find(node1, node2)
+	return found
 }
 
-// EdgeWeight returns the weight of the edge from node1 to node2
+// EdgeWeight returns the weight of the edge from node1 to node2, or 0 if there is no edge
 func (n Network) EdgeWeight(node1, node2 int) uint8 {
-	return n.neighbors[node1][node2]
+	i, found := n.find(node1, node2)
+	if !found {
+		return 0
+	}
+	return n.weights[int(n.start[node1])+i]
 }
 
-// AddEdge adds an edge between node1 and node2 with the given weight
+// AddEdge adds an edge between node1 and node2 with the given weight.
+// If the edge already exists its weight is replaced.
 func (n *Network) AddEdge(node1, node2 int, weight uint8) {
-	n.neighbors[node1][node2] = weight
-	n.neighbors[node2][node1] = weight
+	if weight > n.maxWeight {
+		n.maxWeight = weight
+	}
+	n.insert(node1, node2, weight)
+	n.insert(node2, node1, weight)
 }
 
 func (n *Network) removeEdge(node1, node2 int) {
-	delete(n.neighbors[node1], node2)
-	delete(n.neighbors[node2], node1)
+	n.remove(node1, node2)
+	n.remove(node2, node1)
+}
+
+// find returns the position of to in from's row, or the position where it would be inserted
+func (n Network) find(from, to int) (int, bool) {
+	row := n.Neighbors(from)
+	i := sort.Search(len(row), func(i int) bool { return row[i] >= int32(to) })
+	return i, i < len(row) && row[i] == int32(to)
+}
+
+// insert adds to to from's row while keeping the row sorted
+func (n *Network) insert(from, to int, weight uint8) {
+	i, found := n.find(from, to)
+	if found {
+		n.weights[int(n.start[from])+i] = weight
+		return
+	}
+	if n.degree[from] == n.capacity[from] {
+		n.growRow(from)
+	}
+	s := int(n.start[from])
+	d := int(n.degree[from])
+	copy(n.targets[s+i+1:s+d+1], n.targets[s+i:s+d])
+	copy(n.weights[s+i+1:s+d+1], n.weights[s+i:s+d])
+	n.targets[s+i] = int32(to)
+	n.weights[s+i] = weight
+	n.degree[from]++
+}
+
+// remove takes to out of from's row if it is there
+func (n *Network) remove(from, to int) {
+	i, found := n.find(from, to)
+	if !found {
+		return
+	}
+	s := int(n.start[from])
+	d := int(n.degree[from])
+	copy(n.targets[s+i:s+d-1], n.targets[s+i+1:s+d])
+	copy(n.weights[s+i:s+d-1], n.weights[s+i+1:s+d])
+	n.degree[from]--
+}
+
+// growRow moves node's row to the end of targets with double the capacity.
+// The space the row used to occupy is reclaimed by compacting once it makes up
+// more than half of targets.
+func (n *Network) growRow(node int) {
+	newCapacity := 2 * n.capacity[node]
+	if newCapacity < minRowCapacity {
+		newCapacity = minRowCapacity
+	}
+	newStart := int32(len(n.targets))
+	n.targets = append(n.targets, n.Neighbors(node)...)
+	n.weights = append(n.weights, n.NeighborWeights(node)...)
+	for i := n.degree[node]; i < newCapacity; i++ {
+		n.targets = append(n.targets, 0)
+		n.weights = append(n.weights, 0)
+	}
+	n.abandoned += int(n.capacity[node])
+	n.start[node] = newStart
+	n.capacity[node] = newCapacity
+
+	if n.abandoned > len(n.targets)/2 {
+		n.compact()
+	}
+}
+
+// compact rebuilds targets and weights without any abandoned slots.
+// Rows keep their unused capacity.
+func (n *Network) compact() {
+	size := len(n.targets) - n.abandoned
+	targets := make([]int32, 0, size)
+	weights := make([]uint8, 0, size)
+	for node := range n.start {
+		s := n.start[node]
+		c := n.capacity[node]
+		n.start[node] = int32(len(targets))
+		targets = append(targets, n.targets[s:s+c]...)
+		weights = append(weights, n.weights[s:s+c]...)
+	}
+	n.targets = targets
+	n.weights = weights
+	n.abandoned = 0
 }
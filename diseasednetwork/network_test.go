@@ -1,20 +1,21 @@
 package diseasednetwork
 
 import (
+	"reflect"
 	"testing"
 )
 
 func makeCompleteDiseasedNet(numNodes, numToInfect int) (DiseasedNetwork, Disease) {
 	net := makeCompleteNetwork(numNodes)
 	dis := NewBasicDisease(0, 0, 0, InfectN{n: numToInfect})
-	diseasedNet := NewDiseasedNetwork([]Disease{dis}, net)
+	diseasedNet := New(&net, []Disease{dis})
 	return diseasedNet, dis
 }
 
 func makeCircularDiseasedNet(numNodes, numToInfect int) (DiseasedNetwork, Disease) {
 	net := makeCircularNetwork(numNodes)
 	dis := NewBasicDisease(0, 0, 0, InfectN{n: numToInfect})
-	diseasedNet := NewDiseasedNetwork([]Disease{dis}, net)
+	diseasedNet := New(&net, []Disease{dis})
 	return diseasedNet, dis
 }
 
@@ -23,7 +24,7 @@ func TestFindNeighbors(t *testing.T) {
 	diseasedNet, _ := makeCompleteDiseasedNet(numNodes, 0)
 
 	for node := 0; node < numNodes; node++ {
-		numNeighbors := len(diseasedNet.findNeighbors(node, -1, 0))
+		numNeighbors := len(diseasedNet.findNeighbors(node, nil, 0))
 		if numNeighbors != numNodes-1 {
 			t.Errorf("Expected %d neighbors for node %d, found %d", numNodes-1, node, numNeighbors)
 		}
@@ -31,7 +32,7 @@ func TestFindNeighbors(t *testing.T) {
 
 	diseasedNet, _ = makeCircularDiseasedNet(numNodes, 0)
 	for node := 0; node < numNodes; node++ {
-		numNeighbors := len(diseasedNet.findNeighbors(node, -1, 0))
+		numNeighbors := len(diseasedNet.findNeighbors(node, nil, 0))
 		if numNeighbors != 2 {
 			t.Errorf("Expected node %d to have 2 neighbors, has %d neighbors", node, numNeighbors)
 		}
@@ -67,3 +68,86 @@ func TestState(t *testing.T) {
 		t.Errorf("Expected 1 removed node, found %d", len(removed))
 	}
 }
+
+func TestAddAndRemoveEdges(t *testing.T) {
+	numNodes := 50
+	network := NewNetwork(numNodes)
+	// give node 0 enough neighbors that its row has to move several times
+	for node := numNodes - 1; node > 0; node-- {
+		network.AddEdge(0, node, uint8(node))
+	}
+	if network.Degree(0) != numNodes-1 {
+		t.Errorf("Expected node 0 to have %d neighbors, has %d", numNodes-1, network.Degree(0))
+	}
+	for i, neighbor := range network.Neighbors(0) {
+		if int(neighbor) != i+1 {
+			t.Errorf("Expected neighbor %d of node 0 to be %d, found %d", i, i+1, neighbor)
+		}
+		if network.EdgeWeight(0, int(neighbor)) != uint8(neighbor) ||
+			network.EdgeWeight(int(neighbor), 0) != uint8(neighbor) {
+			t.Errorf("Expected edge 0-%d to have weight %d", neighbor, neighbor)
+		}
+	}
+
+	network.AddEdge(0, 10, 99)
+	if network.Degree(0) != numNodes-1 || network.EdgeWeight(10, 0) != 99 {
+		t.Errorf("Expected adding an existing edge to only change its weight")
+	}
+
+	for node := 2; node < numNodes; node += 2 {
+		network.removeEdge(node, 0)
+	}
+	network.compact()
+	for node := 1; node < numNodes; node++ {
+		if network.HasEdge(0, node) != (node%2 == 1) {
+			t.Errorf("Expected HasEdge(0, %d) to be %v", node, node%2 == 1)
+		}
+		if network.HasEdge(node, 0) != network.HasEdge(0, node) {
+			t.Errorf("Expected edge 0-%d to be undirected", node)
+		}
+	}
+}
+
+func TestNetworkCopyIsIndependent(t *testing.T) {
+	network := makeCircularNetwork(10)
+	networkCopy := network.MakeCopy()
+	networkCopy.removeEdge(0, 1)
+	networkCopy.AddEdge(0, 5, 1)
+	if !network.HasEdge(0, 1) || network.HasEdge(0, 5) {
+		t.Errorf("Changing the copy changed the original network")
+	}
+	if networkCopy.HasEdge(1, 0) || !networkCopy.HasEdge(5, 0) {
+		t.Errorf("Expected the copy to change")
+	}
+	if len(network.NeighborsOf(0)) != 2 || network.NeighborsOf(0)[9] != 1 {
+		t.Errorf("Expected NeighborsOf(0) to be {1: 1, 9: 1}, found %v", network.NeighborsOf(0))
+	}
+}
+
+// TestNetworkCopies pins down what each kind of copy may do. Plain copies may only be read,
+// while copies from MakeCopy may be changed even when rows have to be moved to grow.
+func TestNetworkCopies(t *testing.T) {
+	network := makeCircularNetwork(10)
+	plainCopy := network
+	for node := 0; node < 10; node++ {
+		if !reflect.DeepEqual(plainCopy.Neighbors(node), network.Neighbors(node)) {
+			t.Errorf("Expected a plain copy to read the same neighbors of %d", node)
+		}
+	}
+
+	networkCopy := network.MakeCopy()
+	for node := 2; node < 10; node++ {
+		network.AddEdge(0, node, 2)
+	}
+	if networkCopy.Degree(0) != 2 || networkCopy.EdgeWeight(0, 5) != 0 {
+		t.Errorf("Expected growing the original to leave the copy alone, found %v", networkCopy.NeighborsOf(0))
+	}
+	for node := 1; node < 10; node++ {
+		if !networkCopy.HasEdge(node, node-1) || !networkCopy.HasEdge(node-1, node) {
+			t.Errorf("Expected the copy to keep the edge between %d and %d", node-1, node)
+		}
+	}
+	if network.Degree(0) != 9 || network.EdgeWeight(5, 0) != 2 {
+		t.Errorf("Expected node 0 to be connected to every node, found %v", network.NeighborsOf(0))
+	}
+}
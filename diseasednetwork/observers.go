@@ -0,0 +1,334 @@
+package diseasednetwork
+
+import "gonum.org/v1/plot/plotter"
+
+// Observer watches a run of a DiseasedNetwork. Observers are added with WithObservers and are
+// called from the goroutine that steps the network, so an observer shouldn't be shared by
+// networks that run at the same time. The network passed to a hook is only valid during the call.
+// Embed BaseObserver to only implement some of the hooks.
+type Observer interface {
+	// RunStarted is called once by New after the initial infections
+	RunStarted(n *DiseasedNetwork)
+	// StateChanged is called whenever node moves to a different compartment of the disease at
+	// diseaseIndex. step is the step the change happened in, counting from 1.
+	StateChanged(diseaseIndex, node int, from, to uint8, step uint)
+	// Infected is called when infector passes the disease at diseaseIndex on to infected.
+	// It comes right after infected's StateChanged.
+	Infected(diseaseIndex, infector, infected int, step uint)
+	// StepEnded is called at the end of every step
+	StepEnded(n *DiseasedNetwork, step uint)
+	// RunEnded is called by Finish
+	RunEnded(n *DiseasedNetwork)
+}
+
+// EdgeObserver is an Observer that also wants to hear about the edges the agents add and remove
+// and the edges dead nodes lose
+type EdgeObserver interface {
+	Observer
+	// EdgeAdded is called when node1 and node2 are connected by an edge with weight
+	EdgeAdded(node1, node2 int, weight uint8, step uint)
+	// EdgeRemoved is called when the edge between node1 and node2 is removed
+	EdgeRemoved(node1, node2 int, step uint)
+}
+
+// BaseObserver ignores everything that happens. It is meant to be embedded in observers that
+// only need some of the hooks.
+type BaseObserver struct{}
+
+// RunStarted does nothing
+func (BaseObserver) RunStarted(n *DiseasedNetwork) {}
+
+// StateChanged does nothing
+func (BaseObserver) StateChanged(diseaseIndex, node int, from, to uint8, step uint) {}
+
+// Infected does nothing
+func (BaseObserver) Infected(diseaseIndex, infector, infected int, step uint) {}
+
+// StepEnded does nothing
+func (BaseObserver) StepEnded(n *DiseasedNetwork, step uint) {}
+
+// RunEnded does nothing
+func (BaseObserver) RunEnded(n *DiseasedNetwork) {}
+
+// observation passes the events of a run on to the observers. Copies of a DiseasedNetwork
+// share it with their diseases, so the observers hear about a run no matter which copy is stepped.
+type observation struct {
+	observers []Observer
+	// edgeObservers are the observers that are also EdgeObservers
+	edgeObservers []EdgeObserver
+	// step is the step that is currently running
+	step uint
+}
+
+func (o *observation) stateChanged(diseaseIndex, node int, from, to uint8) {
+	for _, observer := range o.observers {
+		observer.StateChanged(diseaseIndex, node, from, to, o.step)
+	}
+}
+
+func (o *observation) infected(diseaseIndex, infector, infected int) {
+	for _, observer := range o.observers {
+		observer.Infected(diseaseIndex, infector, infected, o.step)
+	}
+}
+
+func (o *observation) edgeAdded(node1, node2 int, weight uint8) {
+	for _, observer := range o.edgeObservers {
+		observer.EdgeAdded(node1, node2, weight, o.step)
+	}
+}
+
+func (o *observation) edgeRemoved(node1, node2 int) {
+	for _, observer := range o.edgeObservers {
+		observer.EdgeRemoved(node1, node2, o.step)
+	}
+}
+
+// Finish tells the observers that the run is over. It should be called once after the last step.
+func (n *DiseasedNetwork) Finish() {
+	for _, observer := range n.events.observers {
+		observer.RunEnded(n)
+	}
+}
+
+// CompartmentCounter records how many nodes are in each compartment of a disease over time
+type CompartmentCounter struct {
+	BaseObserver
+	diseaseIndex int
+	counts       [][]int
+}
+
+// NewCompartmentCounter returns a CompartmentCounter for the disease at diseaseIndex
+func NewCompartmentCounter(diseaseIndex int) *CompartmentCounter {
+	return &CompartmentCounter{diseaseIndex: diseaseIndex}
+}
+
+// RunStarted counts the compartments after the initial infections
+func (c *CompartmentCounter) RunStarted(n *DiseasedNetwork) {
+	c.counts = [][]int{c.count(n)}
+}
+
+// StepEnded counts the compartments after step
+func (c *CompartmentCounter) StepEnded(n *DiseasedNetwork, step uint) {
+	c.counts = append(c.counts, c.count(n))
+}
+
+func (c *CompartmentCounter) count(n *DiseasedNetwork) []int {
+	disease := n.diseases[c.diseaseIndex]
+	counts := make([]int, len(disease.Compartments()))
+	for compartment := range counts {
+		counts[compartment] = disease.NumInState(compartment)
+	}
+	return counts
+}
+
+// Counts returns the number of nodes in each compartment at the start of the run and after
+// every step, indexed by step and then by compartment
+func (c *CompartmentCounter) Counts() [][]int {
+	return c.counts
+}
+
+// Points returns the number of nodes in compartment after each step
+func (c *CompartmentCounter) Points(compartment int) plotter.XYs {
+	points := make(plotter.XYs, len(c.counts))
+	for step, counts := range c.counts {
+		points[step] = plotter.XY{X: float64(step), Y: float64(counts[compartment])}
+	}
+	return points
+}
+
+// IncidenceCounter records the number of new infections of a disease in each step. A node is
+// counted when it leaves a susceptible compartment for the exposure or seed compartment, so
+// transmissions, importations and later waves of seeding are counted, but vaccinations aren't.
+// The count for step 0 is the number of nodes infected at the start.
+type IncidenceCounter struct {
+	BaseObserver
+	diseaseIndex int
+	model        *compiledModel
+	incidence    []int
+}
+
+// NewIncidenceCounter returns an IncidenceCounter for the disease at diseaseIndex
+func NewIncidenceCounter(diseaseIndex int) *IncidenceCounter {
+	return &IncidenceCounter{diseaseIndex: diseaseIndex}
+}
+
+// RunStarted counts the initial infections
+func (c *IncidenceCounter) RunStarted(n *DiseasedNetwork) {
+	c.model = n.diseases[c.diseaseIndex].model()
+	c.incidence = []int{n.NumInfected(c.diseaseIndex)}
+}
+
+// StateChanged counts node if it was just infected
+func (c *IncidenceCounter) StateChanged(diseaseIndex, node int, from, to uint8, step uint) {
+	if diseaseIndex != c.diseaseIndex || !c.model.susceptible[from] ||
+		(to != c.model.Exposure && to != c.model.Seed) {
+		return
+	}
+	c.grow(step)
+	c.incidence[step]++
+}
+
+// StepEnded makes sure steps without any infections are recorded
+func (c *IncidenceCounter) StepEnded(n *DiseasedNetwork, step uint) {
+	c.grow(step)
+}
+
+// grow makes room for the count of step
+func (c *IncidenceCounter) grow(step uint) {
+	for uint(len(c.incidence)) <= step {
+		c.incidence = append(c.incidence, 0)
+	}
+}
+
+// Incidence returns the number of new infections in each step
+func (c *IncidenceCounter) Incidence() []int {
+	return c.incidence
+}
+
+// Points returns the number of new infections in each step
+func (c *IncidenceCounter) Points() plotter.XYs {
+	points := make(plotter.XYs, len(c.incidence))
+	for step, count := range c.incidence {
+		points[step] = plotter.XY{X: float64(step), Y: float64(count)}
+	}
+	return points
+}
+
+// R0Observer records the estimate of R0 for a disease after every step (see InfectionTree.R0)
+type R0Observer struct {
+	BaseObserver
+	diseaseIndex int
+	points       plotter.XYs
+}
+
+// NewR0Observer returns an R0Observer for the disease at diseaseIndex
+func NewR0Observer(diseaseIndex int) *R0Observer {
+	return &R0Observer{diseaseIndex: diseaseIndex}
+}
+
+// StepEnded records the estimate of R0 after step
+func (o *R0Observer) StepEnded(n *DiseasedNetwork, step uint) {
+	o.points = append(o.points, plotter.XY{X: float64(step), Y: n.R0(o.diseaseIndex)})
+}
+
+// Points returns the estimate of R0 after each step
+func (o *R0Observer) Points() plotter.XYs {
+	return o.points
+}
+
+// DegreeObserver records how the agents rewire the network. After every step it records the
+// mean degree and the number of nodes whose degree changed during the step.
+type DegreeObserver struct {
+	BaseObserver
+	degrees    []int
+	meanDegree []float64
+	changed    []int
+}
+
+// NewDegreeObserver returns an instance of DegreeObserver
+func NewDegreeObserver() *DegreeObserver {
+	return &DegreeObserver{}
+}
+
+// RunStarted records the degrees the network starts with
+func (o *DegreeObserver) RunStarted(n *DiseasedNetwork) {
+	o.degrees = make([]int, n.NumNodes())
+	o.meanDegree = []float64{o.update(n)}
+	o.changed = []int{0}
+}
+
+// StepEnded records the changes made in step
+func (o *DegreeObserver) StepEnded(n *DiseasedNetwork, step uint) {
+	before := append([]int(nil), o.degrees...)
+	o.meanDegree = append(o.meanDegree, o.update(n))
+	changed := 0
+	for node, degree := range o.degrees {
+		if degree != before[node] {
+			changed++
+		}
+	}
+	o.changed = append(o.changed, changed)
+}
+
+// update stores the current degree of every node and returns the mean
+func (o *DegreeObserver) update(n *DiseasedNetwork) float64 {
+	total := 0
+	for node := range o.degrees {
+		o.degrees[node] = n.Degree(node)
+		total += o.degrees[node]
+	}
+	if len(o.degrees) == 0 {
+		return 0
+	}
+	return float64(total) / float64(len(o.degrees))
+}
+
+// MeanDegree returns the mean degree at the start and after every step
+func (o *DegreeObserver) MeanDegree() []float64 {
+	return o.meanDegree
+}
+
+// Changed returns the number of nodes whose degree changed in each step.
+// It is always 0 for step 0.
+func (o *DegreeObserver) Changed() []int {
+	return o.changed
+}
+
+// Points returns the mean degree after each step
+func (o *DegreeObserver) Points() plotter.XYs {
+	points := make(plotter.XYs, len(o.meanDegree))
+	for step, mean := range o.meanDegree {
+		points[step] = plotter.XY{X: float64(step), Y: mean}
+	}
+	return points
+}
+
+// EpidemicCurveRecorder records how many nodes are in each compartment of every disease at the
+// start of the run and after every step
+type EpidemicCurveRecorder struct {
+	BaseObserver
+	counters []*CompartmentCounter
+	names    [][]string
+}
+
+// NewEpidemicCurveRecorder returns an instance of EpidemicCurveRecorder
+func NewEpidemicCurveRecorder() *EpidemicCurveRecorder {
+	return &EpidemicCurveRecorder{}
+}
+
+// RunStarted counts the compartments of every disease after the initial infections
+func (r *EpidemicCurveRecorder) RunStarted(n *DiseasedNetwork) {
+	r.counters = make([]*CompartmentCounter, len(n.diseases))
+	r.names = make([][]string, len(n.diseases))
+	for i, disease := range n.diseases {
+		r.counters[i] = NewCompartmentCounter(i)
+		r.counters[i].RunStarted(n)
+		for _, compartment := range disease.Compartments() {
+			r.names[i] = append(r.names[i], compartment.Name)
+		}
+	}
+}
+
+// StepEnded counts the compartments of every disease after step
+func (r *EpidemicCurveRecorder) StepEnded(n *DiseasedNetwork, step uint) {
+	for _, counter := range r.counters {
+		counter.StepEnded(n, step)
+	}
+}
+
+// NumDiseases returns the number of diseases that were recorded
+func (r *EpidemicCurveRecorder) NumDiseases() int {
+	return len(r.counters)
+}
+
+// Counts returns the number of nodes in each compartment of the disease at diseaseIndex,
+// indexed by step and then by compartment. See CompartmentCounter.Counts.
+func (r *EpidemicCurveRecorder) Counts(diseaseIndex int) [][]int {
+	return r.counters[diseaseIndex].Counts()
+}
+
+// CompartmentNames returns the names of the compartments of the disease at diseaseIndex
+func (r *EpidemicCurveRecorder) CompartmentNames(diseaseIndex int) []string {
+	return r.names[diseaseIndex]
+}
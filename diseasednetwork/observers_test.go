@@ -0,0 +1,185 @@
+package diseasednetwork
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/GaudiestTooth17/infection-resistant-network/dynamicnet"
+)
+
+// recordingObserver writes down every event it hears about
+type recordingObserver struct {
+	events []string
+}
+
+func (r *recordingObserver) RunStarted(n *DiseasedNetwork) {
+	r.events = append(r.events, "start")
+}
+
+func (r *recordingObserver) StateChanged(diseaseIndex, node int, from, to uint8, step uint) {
+	r.events = append(r.events, fmt.Sprintf("%d: %d %d %d->%d", step, diseaseIndex, node, from, to))
+}
+
+func (r *recordingObserver) Infected(diseaseIndex, infector, infected int, step uint) {
+	r.events = append(r.events, fmt.Sprintf("%d: %d %d infected %d", step, diseaseIndex, infector, infected))
+}
+
+func (r *recordingObserver) StepEnded(n *DiseasedNetwork, step uint) {
+	r.events = append(r.events, fmt.Sprintf("end of %d", step))
+}
+
+func (r *recordingObserver) RunEnded(n *DiseasedNetwork) {
+	r.events = append(r.events, "end")
+}
+
+// makeObservedStar makes a star with 10 leaves whose infected center infects every leaf in the
+// first step. Everyone recovers in the second step.
+func makeObservedStar(t *testing.T, observers ...Observer) DiseasedNetwork {
+	adjMat := NewNetwork(11)
+	for node := 1; node < 11; node++ {
+		adjMat.AddEdge(0, node, 1)
+	}
+	disease, err := NewCompartmentalDisease(SIRModel(NewFixedDuration(1)), 1, NewInfectNodes(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return New(&adjMat, []Disease{disease}, WithObservers(observers...))
+}
+
+func TestObserverHooks(t *testing.T) {
+	recorder := &recordingObserver{}
+	net := makeObservedStar(t, recorder)
+	for step := 0; step < 3; step++ {
+		net.Step()
+	}
+	net.Finish()
+
+	expected := []string{"start"}
+	for leaf := 1; leaf < 11; leaf++ {
+		expected = append(expected, fmt.Sprintf("1: 0 %d 0->2", leaf), fmt.Sprintf("1: 0 0 infected %d", leaf))
+	}
+	expected = append(expected, "end of 1")
+	recoveries := make(map[string]bool)
+	for node := 0; node < 11; node++ {
+		recoveries[fmt.Sprintf("2: 0 %d 2->3", node)] = true
+	}
+	for _, event := range recorder.events[len(expected) : len(expected)+11] {
+		if !recoveries[event] {
+			t.Errorf("Expected a recovery in step 2, found %s", event)
+		}
+		delete(recoveries, event)
+	}
+	if !reflect.DeepEqual(recorder.events[:len(expected)], expected) {
+		t.Errorf("Expected the events of step 1 to be %v, found %v", expected, recorder.events[:len(expected)])
+	}
+	if !reflect.DeepEqual(recorder.events[len(expected)+11:], []string{"end of 2", "end of 3", "end"}) {
+		t.Errorf("Expected the run to end quietly, found %v", recorder.events[len(expected)+11:])
+	}
+}
+
+func TestBuiltInObservers(t *testing.T) {
+	compartments := NewCompartmentCounter(0)
+	incidence := NewIncidenceCounter(0)
+	r0 := NewR0Observer(0)
+	net := makeObservedStar(t, compartments, incidence, r0)
+	for step := 0; step < 3; step++ {
+		net.Step()
+	}
+
+	expectedCounts := [][]int{{10, 0, 1, 0, 0}, {0, 0, 11, 0, 0}, {0, 0, 0, 11, 0}, {0, 0, 0, 11, 0}}
+	if !reflect.DeepEqual(compartments.Counts(), expectedCounts) {
+		t.Errorf("Expected compartment counts of %v, found %v", expectedCounts, compartments.Counts())
+	}
+	if points := compartments.Points(StateR); points[2].X != 2 || points[2].Y != 11 {
+		t.Errorf("Expected 11 recovered nodes after step 2, found %v", points[2])
+	}
+	if !reflect.DeepEqual(incidence.Incidence(), []int{1, 10, 0, 0}) {
+		t.Errorf("Expected an incidence of [1 10 0 0], found %v", incidence.Incidence())
+	}
+	expectedR0 := []float64{0, 10, 10}
+	for step, point := range r0.Points() {
+		if point.X != float64(step+1) || point.Y != expectedR0[step] {
+			t.Errorf("Expected an R0 of %f after step %d, found %v", expectedR0[step], step+1, point)
+		}
+	}
+}
+
+// TestIncidenceIgnoresVaccination makes sure vaccinated nodes aren't counted as infections
+func TestIncidenceIgnoresVaccination(t *testing.T) {
+	adjMat := makeCircularNetwork(30)
+	incidence := NewIncidenceCounter(0)
+	net := New(&adjMat, []Disease{NewBasicDisease(1, 3, 0, NewInfectN(0))},
+		WithObservers(incidence), WithImportations(NewPulseImportation(2, 2, 1, 1)),
+		WithInterventions(NewIntervention(NewRandomTargeting(), 10, 1)))
+	for step := 0; step < 3; step++ {
+		net.Step()
+	}
+	if !reflect.DeepEqual(incidence.Incidence(), []int{0, 0, 2, 0}) {
+		t.Errorf("Expected only the 2 imported infections to be counted, found %v", incidence.Incidence())
+	}
+}
+
+func TestDegreeObserver(t *testing.T) {
+	adjMat := makeCompleteNetwork(20)
+	degrees := NewDegreeObserver()
+	net := New(&adjMat, []Disease{NewBasicDisease(0, 100, 0, NewInfectNodes(0))},
+		WithObservers(degrees), WithBehavior(dynamicnet.NewSimpleBehavior(0, 19, 1, 0)))
+	net.Step()
+	net.Step()
+
+	if !reflect.DeepEqual(degrees.MeanDegree(), []float64{19, 17.1, 17.1}) {
+		t.Errorf("Expected the mean degree to be [19 17.1 17.1], found %v", degrees.MeanDegree())
+	}
+	if !reflect.DeepEqual(degrees.Changed(), []int{0, 20, 0}) {
+		t.Errorf("Expected every node's degree to change in the first step, found %v", degrees.Changed())
+	}
+}
+
+func TestEpidemicCurveRecorder(t *testing.T) {
+	adjMat := makeCompleteNetwork(10)
+	recorder := NewEpidemicCurveRecorder()
+	bad := NewBasicDisease(1, 1, 1, NewInfectNodes(0))
+	good := NewGoodDisease(1, 1, 0, NewInfectNodes(1, 2))
+	net := New(&adjMat, []Disease{bad, good}, WithObservers(recorder))
+	net.Step()
+
+	if recorder.NumDiseases() != 2 {
+		t.Fatalf("Expected 2 diseases to be recorded, found %d", recorder.NumDiseases())
+	}
+	if !reflect.DeepEqual(recorder.CompartmentNames(0), []string{"S", "E", "I", "R", "D"}) {
+		t.Errorf("Expected the SEIR compartment names, found %v", recorder.CompartmentNames(0))
+	}
+	expectedBad := [][]int{{9, 0, 1, 0, 0}, {0, 9, 1, 0, 0}}
+	if !reflect.DeepEqual(recorder.Counts(0), expectedBad) {
+		t.Errorf("Expected the bad disease to have counts %v, found %v", expectedBad, recorder.Counts(0))
+	}
+	if recorder.Counts(1)[0][StateI] != 2 || len(recorder.Counts(1)) != 2 {
+		t.Errorf("Expected the good disease to start with 2 infected nodes, found %v", recorder.Counts(1))
+	}
+}
+
+// edgeRecorder writes down the edges that are removed
+type edgeRecorder struct {
+	BaseObserver
+	removed []string
+}
+
+func (r *edgeRecorder) EdgeAdded(node1, node2 int, weight uint8, step uint) {}
+
+func (r *edgeRecorder) EdgeRemoved(node1, node2 int, step uint) {
+	r.removed = append(r.removed, fmt.Sprintf("%d: %d-%d", step, node1, node2))
+}
+
+func TestEdgeObserver(t *testing.T) {
+	adjMat := makeCompleteNetwork(5)
+	recorder := &edgeRecorder{}
+	net := New(&adjMat, []Disease{NewBasicDisease(0, 100, 0, NewInfectNodes(0))},
+		WithObservers(recorder), WithBehavior(dynamicnet.NewSimpleBehavior(0, 4, 1, 0)))
+	net.Step()
+	net.Step()
+	expected := []string{"1: 1-0", "1: 2-0", "1: 3-0", "1: 4-0"}
+	if !reflect.DeepEqual(recorder.removed, expected) {
+		t.Errorf("Expected the edges removed to be %v, found %v", expected, recorder.removed)
+	}
+}
@@ -0,0 +1,91 @@
+package diseasednetwork
+
+import (
+	"math/rand"
+
+	"github.com/GaudiestTooth17/infection-resistant-network/dynamicnet"
+)
+
+// Option configures a DiseasedNetwork made by New. Options are applied in order before the
+// initial infections are chosen.
+type Option func(n *DiseasedNetwork)
+
+// WithSeed sets the seed that all of the randomness in the simulation comes from.
+// Networks made without it use a seed of 0.
+func WithSeed(seed int64) Option {
+	return func(n *DiseasedNetwork) {
+		n.rand = rand.New(rand.NewSource(seed))
+	}
+}
+
+// WithBehavior makes the agents adapt to the bad disease according to behavior.
+// Networks made without it never change.
+func WithBehavior(behavior dynamicnet.AgentBehavior) Option {
+	return func(n *DiseasedNetwork) {
+		n.behavior = behavior
+	}
+}
+
+// WithObservers adds observers that watch the run. See Observer and EdgeObserver.
+func WithObservers(observers ...Observer) Option {
+	return func(n *DiseasedNetwork) {
+		n.events.observers = append(n.events.observers, observers...)
+		for _, observer := range observers {
+			if edgeObserver, ok := observer.(EdgeObserver); ok {
+				n.events.edgeObservers = append(n.events.edgeObservers, edgeObserver)
+			}
+		}
+	}
+}
+
+// WithInterventions adds vaccination campaigns. See SetInterventions.
+func WithInterventions(interventions ...Intervention) Option {
+	return func(n *DiseasedNetwork) {
+		if len(interventions) > 0 {
+			n.SetInterventions(append(n.interventions, interventions...))
+		}
+	}
+}
+
+// WithEngine chooses how the diseases move forward in each step. See SetEngine.
+func WithEngine(engine Engine) Option {
+	return func(n *DiseasedNetwork) {
+		n.SetEngine(engine)
+	}
+}
+
+// WithTransmission chooses how edge weights change the chance of infection. See SetTransmission.
+func WithTransmission(transmission TransmissionFunc) Option {
+	return func(n *DiseasedNetwork) {
+		n.SetTransmission(transmission)
+	}
+}
+
+// WithNodeAttributes gives every node its own attributes. See SetNodeAttributes.
+func WithNodeAttributes(attributes []NodeAttributes) Option {
+	return func(n *DiseasedNetwork) {
+		n.SetNodeAttributes(attributes)
+	}
+}
+
+// WithImportations adds ways for infections to be brought in from outside. See SetImportations.
+func WithImportations(importations ...Importation) Option {
+	return func(n *DiseasedNetwork) {
+		n.SetImportations(append(n.importations, importations...))
+	}
+}
+
+// WithInteractions sets the ways the diseases affect each other. See SetInteractions.
+func WithInteractions(interactions ...Interaction) Option {
+	return func(n *DiseasedNetwork) {
+		n.SetInteractions(interactions)
+	}
+}
+
+// WithAwareBehavior makes the nodes infected with the disease at diseaseIndex follow behavior.
+// See SetAwareBehavior.
+func WithAwareBehavior(diseaseIndex int, behavior dynamicnet.AgentBehavior) Option {
+	return func(n *DiseasedNetwork) {
+		n.SetAwareBehavior(diseaseIndex, behavior)
+	}
+}
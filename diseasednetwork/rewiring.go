@@ -0,0 +1,81 @@
+package diseasednetwork
+
+import (
+	"sort"
+
+	"github.com/GaudiestTooth17/infection-resistant-network/dynamicnet"
+)
+
+// rewire lets every agent react to the bad disease (slot 0) by cutting ties to infected
+// neighbors and by forming new ties with the neighbors of its neighbors.
+// Agents without a behavior (see behaviorOf) don't rewire.
+func (n *DiseasedNetwork) rewire() {
+	if n.behavior == nil && n.awareBehavior == nil {
+		return
+	}
+	for node := 0; node < n.NumNodes(); node++ {
+		behavior := n.behaviorOf(node)
+		if behavior == nil {
+			continue
+		}
+		n.removeInfectedNeighbors(node, behavior)
+		n.addNeighborOfNeighbor(node, behavior)
+	}
+}
+
+// removeInfectedNeighbors removes each of node's infectious neighbors with probability
+// RemoveInfectedNeighborProb. node will not drop below MinConnections neighbors.
+// Only the degree of node is considered; the infected neighbor has no say in the matter.
+func (n *DiseasedNetwork) removeInfectedNeighbors(node int, behavior dynamicnet.AgentBehavior) {
+	infectedNeighbors := n.findNeighbors(node, n.diseases[0].model().infectious, 0)
+	for _, neighbor := range infectedNeighbors {
+		if n.Degree(node) <= behavior.MinConnections() {
+			return
+		}
+		if n.rand.Float32() < behavior.RemoveInfectedNeighborProb() {
+			n.removeEdge(node, int(neighbor))
+		}
+	}
+}
+
+// addNeighborOfNeighbor connects node to a random neighbor of one of its neighbors
+// with probability AddNeighborOfNeighborProb. Infectious nodes are never chosen and
+// neither node will grow above MaxConnections neighbors.
+func (n *DiseasedNetwork) addNeighborOfNeighbor(node int, behavior dynamicnet.AgentBehavior) {
+	maxConnections := behavior.MaxConnections()
+	if n.Degree(node) >= maxConnections ||
+		n.rand.Float32() >= behavior.AddNeighborOfNeighborProb() {
+		return
+	}
+
+	infectious := n.diseases[0].model().infectious
+	candidates := make([]int32, 0)
+	for _, neighbor := range n.adjMat.Neighbors(node) {
+		for _, candidate := range n.adjMat.Neighbors(int(neighbor)) {
+			if int(candidate) == node || n.adjMat.HasEdge(node, int(candidate)) ||
+				infectious[n.diseases[0].State(int(candidate))] ||
+				n.Degree(int(candidate)) >= maxConnections {
+				continue
+			}
+			candidates = append(candidates, candidate)
+		}
+	}
+	candidates = uniqueNodes(candidates)
+	if len(candidates) == 0 {
+		return
+	}
+	n.addEdge(node, int(candidates[n.rand.Intn(len(candidates))]), 1)
+}
+
+// uniqueNodes sorts nodes and removes any duplicates so that random choices
+// made from them are uniform and do not depend on the order they were found in
+func uniqueNodes(nodes []int32) []int32 {
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i] < nodes[j] })
+	unique := nodes[:0]
+	for _, node := range nodes {
+		if len(unique) == 0 || node != unique[len(unique)-1] {
+			unique = append(unique, node)
+		}
+	}
+	return unique
+}
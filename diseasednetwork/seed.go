@@ -0,0 +1,11 @@
+package diseasednetwork
+
+// DeriveSeed returns the seed for the stream numbered stream that belongs to the master seed.
+// The seeds are scrambled with SplitMix64 so that neighboring streams are independent
+// even though the master seed and stream numbers are close together.
+func DeriveSeed(seed int64, stream int) int64 {
+	z := uint64(seed) + (uint64(stream)+1)*0x9e3779b97f4a7c15
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return int64(z ^ (z >> 31))
+}
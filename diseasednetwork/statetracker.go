@@ -0,0 +1,141 @@
+package diseasednetwork
+
+import (
+	"math"
+	"math/rand"
+)
+
+// stateTracker stores the state of every node along with the set of nodes in each state.
+// The sets are updated whenever a state changes, so nodes in a state can be counted in O(1)
+// and visited without scanning the whole network.
+// Instead of counting up the time in state of every node each step, the tracker remembers the
+// step each node entered its state, so advancing time is also O(1).
+type stateTracker struct {
+	state []uint8
+	// entered is the step each node entered its current state
+	entered []int32
+	now     int32
+	// members holds the nodes in each state in no particular order
+	members [][]int32
+	// position is the index of each node in its state's entry in members
+	position []int32
+	// due is the number of steps each node will spend in its current state,
+	// or -1 if it hasn't been drawn yet
+	due []int16
+}
+
+// newStateTracker returns a stateTracker for numStates states with every node in state 0
+func newStateTracker(numNodes, numStates int) stateTracker {
+	t := stateTracker{
+		state:    make([]uint8, numNodes),
+		members:  make([][]int32, numStates),
+		entered:  make([]int32, numNodes),
+		position: make([]int32, numNodes),
+		due:      make([]int16, numNodes),
+	}
+	t.members[0] = make([]int32, numNodes)
+	for node := 0; node < numNodes; node++ {
+		t.members[0][node] = int32(node)
+		t.position[node] = int32(node)
+		t.due[node] = -1
+	}
+	return t
+}
+
+func (t *stateTracker) stateOf(node int) uint8 {
+	return t.state[node]
+}
+
+// setState moves node to state and resets its time in state. A new duration will be drawn
+// for the state the next time timeIsUp is called.
+func (t *stateTracker) setState(node int, state uint8) {
+	old := t.state[node]
+	if old != state {
+		// swap the last member of the old state into node's place
+		oldMembers := t.members[old]
+		last := oldMembers[len(oldMembers)-1]
+		oldMembers[t.position[node]] = last
+		t.position[last] = t.position[node]
+		t.members[old] = oldMembers[:len(oldMembers)-1]
+
+		t.position[node] = int32(len(t.members[state]))
+		t.members[state] = append(t.members[state], int32(node))
+		t.state[node] = state
+	}
+	t.entered[node] = t.now
+	t.due[node] = -1
+}
+
+// count returns the number of nodes in state
+func (t *stateTracker) count(state int) int {
+	return len(t.members[state])
+}
+
+// nodesIn returns the nodes in state. The slice belongs to the tracker, so it must not be
+// modified and is only valid until the next call to setState.
+func (t *stateTracker) nodesIn(state int) []int32 {
+	return t.members[state]
+}
+
+// findNodesInState returns the nodes in state as a set
+func (t *stateTracker) findNodesInState(state int) map[int]Void {
+	nodes := make(map[int]Void, t.count(state))
+	for _, node := range t.members[state] {
+		nodes[int(node)] = Void{}
+	}
+	return nodes
+}
+
+// timeInState returns the number of steps node has been in its state.
+// It saturates at the largest int16.
+func (t *stateTracker) timeInState(node int) int16 {
+	time := t.now - t.entered[node]
+	if time > math.MaxInt16 {
+		return math.MaxInt16
+	}
+	return int16(time)
+}
+
+func (t *stateTracker) resetTimeInState(node int) {
+	t.entered[node] = t.now
+}
+
+func (t *stateTracker) incTimeInState(node int) {
+	t.entered[node]--
+}
+
+// timeIsUp reports whether node has spent its whole duration in its current state.
+// If node doesn't have a duration yet, one is drawn from duration. Durations are drawn the
+// first time they are needed instead of when a node changes state so that setState doesn't
+// need any random numbers.
+func (t *stateTracker) timeIsUp(node int, duration DurationDistribution, rand *rand.Rand) bool {
+	if t.due[node] < 0 {
+		t.due[node] = duration.sample(rand)
+	}
+	return t.timeInState(node) >= t.due[node]
+}
+
+// advanceTime increases the time in state of every node by one step
+func (t *stateTracker) advanceTime() {
+	t.now++
+}
+
+func (t *stateTracker) makeCopy() stateTracker {
+	c := stateTracker{
+		state:    make([]uint8, len(t.state)),
+		entered:  make([]int32, len(t.entered)),
+		now:      t.now,
+		members:  make([][]int32, len(t.members)),
+		position: make([]int32, len(t.position)),
+		due:      make([]int16, len(t.due)),
+	}
+	copy(c.state, t.state)
+	copy(c.due, t.due)
+	copy(c.entered, t.entered)
+	copy(c.position, t.position)
+	for state, members := range t.members {
+		c.members[state] = make([]int32, len(members))
+		copy(c.members[state], members)
+	}
+	return c
+}
@@ -0,0 +1,53 @@
+package diseasednetwork
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// TestStateTrackerSets changes states at random and makes sure the sets of nodes
+// in each state always agree with the states of the nodes
+func TestStateTrackerSets(t *testing.T) {
+	numNodes := 200
+	numStates := 5
+	tracker := newStateTracker(numNodes, numStates)
+	rand := rand.New(rand.NewSource(3))
+	for i := 0; i < 5000; i++ {
+		tracker.setState(rand.Intn(numNodes), uint8(rand.Intn(numStates)))
+	}
+
+	total := 0
+	for state := 0; state < numStates; state++ {
+		members := tracker.nodesIn(state)
+		total += tracker.count(state)
+		for i, node := range members {
+			if tracker.stateOf(int(node)) != uint8(state) {
+				t.Errorf("Node %d is in the set for state %d but has state %d",
+					node, state, tracker.stateOf(int(node)))
+			}
+			if tracker.position[node] != int32(i) {
+				t.Errorf("Node %d is at index %d but thinks it is at %d", node, i, tracker.position[node])
+			}
+		}
+	}
+	if total != numNodes {
+		t.Errorf("Expected the sets to hold %d nodes, found %d", numNodes, total)
+	}
+}
+
+func TestTimeInState(t *testing.T) {
+	tracker := newStateTracker(3, 4)
+	tracker.advanceTime()
+	tracker.advanceTime()
+	tracker.setState(1, StateI)
+	tracker.advanceTime()
+	tracker.incTimeInState(2)
+	if tracker.timeInState(0) != 3 || tracker.timeInState(1) != 1 || tracker.timeInState(2) != 4 {
+		t.Errorf("Expected times in state 3, 1, 4, found %d, %d, %d",
+			tracker.timeInState(0), tracker.timeInState(1), tracker.timeInState(2))
+	}
+	tracker.resetTimeInState(0)
+	if tracker.timeInState(0) != 0 {
+		t.Errorf("Expected time in state to be 0 after a reset, found %d", tracker.timeInState(0))
+	}
+}
@@ -0,0 +1,53 @@
+package diseasednetwork
+
+import "math"
+
+// TransmissionFunc gives the probability that an infectious node infects a susceptible neighbor
+// during one step. It is given the disease's infection probability and the weight of the edge
+// between the two nodes, and should return a probability between 0 and 1.
+type TransmissionFunc func(infectionProbability float32, weight uint8) float32
+
+// IndependentContacts treats an edge with weight w as w separate contacts that each have a chance
+// to transmit the disease, so the probability is 1-(1-p)^w. This is the default.
+// A weight of 1 gives back the infection probability unchanged.
+func IndependentContacts(infectionProbability float32, weight uint8) float32 {
+	if weight == 1 {
+		return infectionProbability
+	}
+	return float32(1 - math.Pow(1-float64(infectionProbability), float64(weight)))
+}
+
+// ProportionalToWeight multiplies the infection probability by the weight of the edge.
+// The probability is capped at 1.
+func ProportionalToWeight(infectionProbability float32, weight uint8) float32 {
+	return float32(math.Min(1, float64(infectionProbability)*float64(weight)))
+}
+
+// IgnoreWeights uses the infection probability for every edge no matter its weight
+func IgnoreWeights(infectionProbability float32, weight uint8) float32 {
+	return infectionProbability
+}
+
+// SetTransmission chooses how the weight of an edge changes the chance of the disease crossing it.
+// Networks start out using IndependentContacts, and setting it to nil goes back to that.
+func (n *DiseasedNetwork) SetTransmission(transmission TransmissionFunc) {
+	if transmission == nil {
+		transmission = IndependentContacts
+	}
+	n.transmission = transmission
+}
+
+// edgeProbability gives the chance that the disease at diseaseIndex crosses the edge from infector
+// to atRiskNode in one step
+func (n *DiseasedNetwork) edgeProbability(diseaseIndex int, infector, atRiskNode int) float32 {
+	disease := n.diseases[diseaseIndex]
+	p := n.transmission(disease.InfectionProbability(), n.adjMat.EdgeWeight(infector, atRiskNode))
+	if n.interactions != nil {
+		p *= n.interactionMultiplier(diseaseIndex, atRiskNode)
+	}
+	p = n.applyAttributes(p, infector, atRiskNode)
+	if p > 1 {
+		return 1
+	}
+	return p
+}
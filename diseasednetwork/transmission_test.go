@@ -0,0 +1,105 @@
+package diseasednetwork
+
+import (
+	"math"
+	"testing"
+)
+
+func TestTransmissionFuncs(t *testing.T) {
+	if p := IndependentContacts(.2, 1); p != .2 {
+		t.Errorf("Expected a weight of 1 to leave the probability alone, found %f", p)
+	}
+	if p := IndependentContacts(.2, 3); math.Abs(float64(p)-.488) > 1e-6 {
+		t.Errorf("Expected 1-(1-.2)^3 = .488, found %f", p)
+	}
+	if p := ProportionalToWeight(.2, 3); math.Abs(float64(p)-.6) > 1e-6 {
+		t.Errorf("Expected .2*3 = .6, found %f", p)
+	}
+	if p := ProportionalToWeight(.2, 10); p != 1 {
+		t.Errorf("Expected the probability to be capped at 1, found %f", p)
+	}
+	if p := IgnoreWeights(.2, 10); p != .2 {
+		t.Errorf("Expected the weight to be ignored, found %f", p)
+	}
+}
+
+// makePairs makes a network of isolated pairs of nodes. The even node in each pair is infected.
+// Half of the pairs are joined by an edge with weight 1 and half with weight heavyWeight.
+func makePairs(t *testing.T, numPairs int, heavyWeight uint8, model CompartmentalModel,
+	engine Engine) DiseasedNetwork {
+	adjMat := NewNetwork(2 * numPairs)
+	for pair := 0; pair < numPairs; pair++ {
+		weight := uint8(1)
+		if pair%2 == 1 {
+			weight = heavyWeight
+		}
+		adjMat.AddEdge(2*pair, 2*pair+1, weight)
+	}
+	disease, err := NewCompartmentalDisease(model, .2, NewInfectN(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	net := NewDiseasedNetwork(&adjMat, []Disease{disease}, nil, []Observer{}, 4)
+	net.SetEngine(engine)
+	for pair := 0; pair < numPairs; pair++ {
+		disease.SetState(2*pair, model.Seed)
+	}
+	return net
+}
+
+// transmittedFraction returns the fraction of the light and heavy pairs where the disease spread
+func transmittedFraction(net *DiseasedNetwork) (light, heavy float64) {
+	numPairs := net.NumNodes() / 2
+	for pair := 0; pair < numPairs; pair++ {
+		if net.diseases[0].State(2*pair+1) == StateS {
+			continue
+		}
+		if pair%2 == 0 {
+			light++
+		} else {
+			heavy++
+		}
+	}
+	return light / float64(numPairs/2), heavy / float64(numPairs/2)
+}
+
+// TestWeightedTransmission gives each infected node a single chance to infect its partner
+func TestWeightedTransmission(t *testing.T) {
+	net := makePairs(t, 4000, 3, SIRModel(NewFixedDuration(0)), DiscreteEngine)
+	net.Step()
+	light, heavy := transmittedFraction(&net)
+	if math.Abs(light-.2) > .03 || math.Abs(heavy-.488) > .03 {
+		t.Errorf("Expected .2 of the light pairs and .488 of the heavy pairs to transmit, found %f and %f",
+			light, heavy)
+	}
+
+	net = makePairs(t, 4000, 3, SIRModel(NewFixedDuration(0)), DiscreteEngine)
+	net.SetTransmission(IgnoreWeights)
+	net.Step()
+	light, heavy = transmittedFraction(&net)
+	if math.Abs(light-heavy) > .04 {
+		t.Errorf("Expected weights to be ignored, found %f of the light pairs and %f of the heavy pairs",
+			light, heavy)
+	}
+}
+
+// TestWeightedGillespie compares the pairs to two competing exponential processes
+func TestWeightedGillespie(t *testing.T) {
+	timeToR := 4.0
+	net := makePairs(t, 4000, 3, SIRModel(NewFixedDuration(int16(timeToR))), GillespieEngine)
+	for step := 0; step < 100; step++ {
+		net.Step()
+	}
+	light, heavy := transmittedFraction(&net)
+	for _, pairs := range []struct {
+		weight uint8
+		found  float64
+	}{{1, light}, {3, heavy}} {
+		beta := transmissionRate(IndependentContacts(.2, pairs.weight))
+		expected := beta / (beta + 1/timeToR)
+		if math.Abs(pairs.found-expected) > .03 {
+			t.Errorf("Expected %f of the pairs with weight %d to transmit, found %f",
+				expected, pairs.weight, pairs.found)
+		}
+	}
+}
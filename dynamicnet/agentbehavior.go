@@ -2,10 +2,16 @@ package dynamicnet
 
 // AgentBehavior defines the way agents in the network react to the spreading infection
 type AgentBehavior interface {
-	removeInfectedNeighborProb() float32
-	minConnections() int
-	maxConnections() int
-	addNeighborOfNeighborProb() float32
+	// RemoveInfectedNeighborProb is the probability that an agent cuts its tie to an
+	// infected neighbor in one time step
+	RemoveInfectedNeighborProb() float32
+	// MinConnections is the fewest neighbors an agent will allow itself to have
+	MinConnections() int
+	// MaxConnections is the most neighbors an agent will allow itself to have
+	MaxConnections() int
+	// AddNeighborOfNeighborProb is the probability that an agent connects to one of
+	// its neighbors' neighbors in one time step
+	AddNeighborOfNeighborProb() float32
 }
 
 type simpleBehavior struct {
@@ -26,18 +32,18 @@ func NewSimpleBehavior(minConnections, maxConnections int,
 	}
 }
 
-func (s simpleBehavior) removeInfectedNeighborProb() float32 {
+func (s simpleBehavior) RemoveInfectedNeighborProb() float32 {
 	return s.removeInfNProb
 }
 
-func (s simpleBehavior) minConnections() int {
+func (s simpleBehavior) MinConnections() int {
 	return s.minConn
 }
 
-func (s simpleBehavior) maxConnections() int {
+func (s simpleBehavior) MaxConnections() int {
 	return s.maxConn
 }
 
-func (s simpleBehavior) addNeighborOfNeighborProb() float32 {
+func (s simpleBehavior) AddNeighborOfNeighborProb() float32 {
 	return s.addNofNProb
 }
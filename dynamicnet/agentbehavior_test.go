@@ -1,41 +1,92 @@
-package dynamicnet
-
-// There is currently no dynamic behavior in networks, so this test has no bearing
-
-// import (
-// 	"testing"
-
-// 	"github.com/GaudiestTooth17/infection-resistant-network/diseasednetwork"
-// 	"github.com/GaudiestTooth17/infection-resistant-network/networkgenerator"
-// )
-
-// const (
-// 	stateS = diseasednetwork.StateS
-// 	stateE = diseasednetwork.StateE
-// 	stateI = diseasednetwork.StateI
-// 	stateR = diseasednetwork.StateR
-// )
-
-// func TestImmuneNetwork(t *testing.T) {
-// 	numNodes := 100
-// 	net := diseasednetwork.NewDiseasedNetwork(
-// 		[]diseasednetwork.Disease{diseasednetwork.NewBasicDisease(1, 1, 1.0, diseasednetwork.NewInfectN(1))},
-// 		networkgenerator.MakeCompleteNetwork(numNodes))
-
-// 	numInfected := len(net.FindNodesInState(stateI, 0))
-// 	if numInfected != 1 {
-// 		t.Errorf("Expected 1 infected node to start with, found %d", numInfected)
-// 	}
-
-// 	for i := 0; i < 20; i++ {
-// 		net.Step()
-// 		numSusceptible := len(net.FindNodesInState(stateS, 0))
-// 		if numSusceptible != numNodes-1 {
-// 			numExposed := len(net.FindNodesInState(stateE, 0))
-// 			numInfected := len(net.FindNodesInState(stateI, 0))
-// 			numRecovered := len(net.FindNodesInState(stateR, 0))
-// 			t.Errorf("(step %d) Expected %d susceptible nodes, found %d.\nAlso found %d exposed, %d infected, %d recovered.\n",
-// 				i, numNodes-1, numSusceptible, numExposed, numInfected, numRecovered)
-// 		}
-// 	}
-// }
+package dynamicnet_test
+
+import (
+	"testing"
+
+	"github.com/GaudiestTooth17/infection-resistant-network/diseasednetwork"
+	"github.com/GaudiestTooth17/infection-resistant-network/dynamicnet"
+	"github.com/GaudiestTooth17/infection-resistant-network/networkgenerator"
+)
+
+const (
+	stateS = diseasednetwork.StateS
+	stateE = diseasednetwork.StateE
+	stateI = diseasednetwork.StateI
+	stateR = diseasednetwork.StateR
+)
+
+// TestImmuneNetwork makes sure that agents who always cut ties to infected neighbors
+// keep the disease from ever leaving the first infected node
+func TestImmuneNetwork(t *testing.T) {
+	numNodes := 100
+	network := networkgenerator.MakeCompleteNetwork(numNodes)
+	net := diseasednetwork.NewDiseasedNetwork(&network,
+		[]diseasednetwork.Disease{diseasednetwork.NewBasicDisease(1, 1, 1.0, diseasednetwork.NewInfectN(1))},
+		dynamicnet.NewSimpleBehavior(0, numNodes, 1.0, 0), []diseasednetwork.Observer{}, 1)
+
+	numInfected := len(net.FindNodesInState(stateI, 0))
+	if numInfected != 1 {
+		t.Errorf("Expected 1 infected node to start with, found %d", numInfected)
+	}
+
+	for i := 0; i < 20; i++ {
+		net.Step()
+		numSusceptible := len(net.FindNodesInState(stateS, 0))
+		if numSusceptible != numNodes-1 {
+			numExposed := len(net.FindNodesInState(stateE, 0))
+			numInfected := len(net.FindNodesInState(stateI, 0))
+			numRecovered := len(net.FindNodesInState(stateR, 0))
+			t.Errorf("(step %d) Expected %d susceptible nodes, found %d.\nAlso found %d exposed, %d infected, %d recovered.\n",
+				i, numNodes-1, numSusceptible, numExposed, numInfected, numRecovered)
+		}
+	}
+}
+
+// TestMinConnections makes sure agents keep their infected neighbors when removing
+// them would put the agents below their minimum number of connections
+func TestMinConnections(t *testing.T) {
+	numNodes := 50
+	network := networkgenerator.MakeCompleteNetwork(numNodes)
+	net := diseasednetwork.NewDiseasedNetwork(&network,
+		[]diseasednetwork.Disease{diseasednetwork.NewBasicDisease(1, 1, 1.0, diseasednetwork.NewInfectN(1))},
+		dynamicnet.NewSimpleBehavior(numNodes-1, numNodes-1, 1.0, 0), []diseasednetwork.Observer{}, 1)
+
+	net.Step()
+	numExposed := len(net.FindNodesInState(stateE, 0))
+	if numExposed != numNodes-1 {
+		t.Errorf("Expected %d exposed nodes after 1 step, found %d", numNodes-1, numExposed)
+	}
+	for node := 0; node < numNodes; node++ {
+		if net.Degree(node) != numNodes-1 {
+			t.Errorf("Expected node %d to keep %d neighbors, has %d", node, numNodes-1, net.Degree(node))
+		}
+	}
+}
+
+// TestMaxConnections makes sure agents that always add neighbors of neighbors stop
+// once they reach their maximum number of connections
+func TestMaxConnections(t *testing.T) {
+	numNodes := 100
+	maxConnections := 4
+	network := networkgenerator.MakeCircularNetwork(numNodes)
+	net := diseasednetwork.NewDiseasedNetwork(&network,
+		[]diseasednetwork.Disease{diseasednetwork.NewBasicDisease(1, 1, 0, diseasednetwork.NewInfectN(0))},
+		dynamicnet.NewSimpleBehavior(0, maxConnections, 0, 1.0), []diseasednetwork.Observer{}, 1)
+
+	for i := 0; i < 10; i++ {
+		net.Step()
+	}
+	grewNetwork := false
+	for node := 0; node < numNodes; node++ {
+		degree := net.Degree(node)
+		if degree > maxConnections {
+			t.Errorf("Expected node %d to have at most %d neighbors, has %d", node, maxConnections, degree)
+		}
+		if degree > 2 {
+			grewNetwork = true
+		}
+	}
+	if !grewNetwork {
+		t.Errorf("Expected some node to gain a neighbor of a neighbor")
+	}
+}
@@ -19,3 +19,10 @@ func (f *Float32Genotype) Len() int {
 func (f *Float32Genotype) Get(i int) float32 {
 	return f.floats[i]
 }
+
+// MakeCopy copies the genotype so that different memory is used for the floats
+func (f *Float32Genotype) MakeCopy() Float32Genotype {
+	floats := make([]float32, len(f.floats))
+	copy(floats, f.floats)
+	return Float32Genotype{floats: floats}
+}
@@ -1,8 +1,17 @@
 package evolution
 
+import (
+	"math/rand"
+	"sort"
+)
+
 // PopulationManager searches the solution space given by its calculator
+// using a generational genetic algorithm
 type PopulationManager struct {
 	population []Float32Genotype
+	fitnesses  []float32
+	config     Config
+	rand       *rand.Rand
 }
 
 // FitnessCalculator takes a genotype and gives it a fitness rating
@@ -11,3 +20,189 @@ type FitnessCalculator interface {
 	// genotype/solution is
 	CalculateFitness(genotype Float32Genotype) float32
 }
+
+// ReseedableCalculator is a FitnessCalculator whose fitness comes from random trials that can
+// be drawn again. Run reseeds it before every generation so that a noisy fitness is measured
+// on new trials each time instead of on the same ones over and over.
+type ReseedableCalculator interface {
+	FitnessCalculator
+	// Reseed returns a copy of the calculator that draws its trials from stream. The same
+	// stream always gives the same trials.
+	Reseed(stream int) FitnessCalculator
+}
+
+// Bounds are the smallest and largest values a single gene may take
+type Bounds struct {
+	Min float32
+	Max float32
+}
+
+// Config holds the parameters of the genetic algorithm
+type Config struct {
+	// PopulationSize is the number of genotypes in each generation
+	PopulationSize int
+	// GeneBounds has one entry for each gene in a genotype
+	GeneBounds []Bounds
+	// InitialPopulation is used before any random genotypes when making the first generation.
+	// Any entries past PopulationSize are ignored.
+	InitialPopulation []Float32Genotype
+	// Selection chooses the parents of the next generation
+	Selection SelectionStrategy
+	// CrossoverRate is the probability that two parents are recombined instead of copied
+	CrossoverRate float32
+	// MutationRate is the probability that each gene of a child is mutated
+	MutationRate float32
+	// MutationScale is the standard deviation of a mutation as a fraction of the gene's range
+	MutationScale float32
+	// NumElites is the number of best genotypes copied unchanged into the next generation
+	NumElites int
+	// Seed seeds the random number generator used by the algorithm
+	Seed int64
+}
+
+// GenerationStats records how a single generation performed
+type GenerationStats struct {
+	Generation   int
+	BestFitness  float32
+	MeanFitness  float32
+	WorstFitness float32
+	BestGenotype Float32Genotype
+}
+
+// NewPopulationManager creates a PopulationManager and its first generation.
+// Genotypes in config.InitialPopulation are used first, and the rest of the
+// population is drawn uniformly from config.GeneBounds.
+func NewPopulationManager(config Config) PopulationManager {
+	if config.PopulationSize < 1 {
+		panic("PopulationSize must be positive.")
+	}
+	if config.NumElites < 0 || config.NumElites > config.PopulationSize {
+		panic("NumElites must be in the range [0, PopulationSize].")
+	}
+	if config.Selection == nil {
+		config.Selection = NewTournamentSelection(2)
+	}
+	manager := PopulationManager{
+		population: make([]Float32Genotype, 0, config.PopulationSize),
+		fitnesses:  make([]float32, config.PopulationSize),
+		config:     config,
+		rand:       rand.New(rand.NewSource(config.Seed)),
+	}
+	for _, genotype := range config.InitialPopulation {
+		if len(manager.population) == config.PopulationSize {
+			break
+		}
+		if genotype.Len() != len(config.GeneBounds) {
+			panic("Initial genotype has the wrong number of genes!")
+		}
+		manager.population = append(manager.population, genotype.MakeCopy())
+	}
+	for len(manager.population) < config.PopulationSize {
+		manager.population = append(manager.population, manager.randomGenotype())
+	}
+	return manager
+}
+
+// Population returns the current generation
+func (m *PopulationManager) Population() []Float32Genotype {
+	return m.population
+}
+
+// Run evolves the population for numGenerations generations. It returns the best
+// genotype found in any generation along with statistics for each generation.
+// Every genotype is reevaluated each generation because fitness may be noisy. If calculator is
+// a ReseedableCalculator, each generation is evaluated on its own trials, so the elites have to
+// prove themselves again. Genotypes in the same generation share trials, which makes them fair
+// to compare.
+func (m *PopulationManager) Run(calculator FitnessCalculator, numGenerations int) (Float32Genotype, []GenerationStats) {
+	history := make([]GenerationStats, 0, numGenerations)
+	var best Float32Genotype
+	bestFitness := float32(-1)
+	reseedable, isReseedable := calculator.(ReseedableCalculator)
+	for generation := 0; generation < numGenerations; generation++ {
+		if isReseedable {
+			m.evaluate(reseedable.Reseed(generation))
+		} else {
+			m.evaluate(calculator)
+		}
+		stats := m.stats(generation)
+		history = append(history, stats)
+		if stats.BestFitness > bestFitness {
+			bestFitness = stats.BestFitness
+			best = stats.BestGenotype
+		}
+		// there is no point in breeding a generation that will never be evaluated
+		if generation < numGenerations-1 {
+			m.nextGeneration()
+		}
+	}
+	return best, history
+}
+
+// evaluate rates every genotype in the population
+func (m *PopulationManager) evaluate(calculator FitnessCalculator) {
+	for i, genotype := range m.population {
+		m.fitnesses[i] = calculator.CalculateFitness(genotype)
+	}
+}
+
+// stats summarizes the fitnesses of the current population
+func (m *PopulationManager) stats(generation int) GenerationStats {
+	stats := GenerationStats{Generation: generation, BestFitness: m.fitnesses[0],
+		WorstFitness: m.fitnesses[0]}
+	bestIndex := 0
+	sum := float32(0)
+	for i, fitness := range m.fitnesses {
+		sum += fitness
+		if fitness > stats.BestFitness {
+			stats.BestFitness = fitness
+			bestIndex = i
+		}
+		if fitness < stats.WorstFitness {
+			stats.WorstFitness = fitness
+		}
+	}
+	stats.MeanFitness = sum / float32(len(m.fitnesses))
+	stats.BestGenotype = m.population[bestIndex].MakeCopy()
+	return stats
+}
+
+// nextGeneration replaces the population with the elites of the current one
+// and children bred from selected parents
+func (m *PopulationManager) nextGeneration() {
+	next := make([]Float32Genotype, 0, m.config.PopulationSize)
+	for _, i := range m.rankedIndices()[:m.config.NumElites] {
+		next = append(next, m.population[i].MakeCopy())
+	}
+	for len(next) < m.config.PopulationSize {
+		parent1 := m.population[m.config.Selection.selectParent(m.fitnesses, m.rand)]
+		parent2 := m.population[m.config.Selection.selectParent(m.fitnesses, m.rand)]
+		child1, child2 := m.crossover(parent1, parent2)
+		next = append(next, m.mutate(child1))
+		if len(next) < m.config.PopulationSize {
+			next = append(next, m.mutate(child2))
+		}
+	}
+	m.population = next
+}
+
+// rankedIndices returns the indices of the population from most to least fit
+func (m *PopulationManager) rankedIndices() []int {
+	indices := make([]int, len(m.population))
+	for i := range indices {
+		indices[i] = i
+	}
+	sort.SliceStable(indices, func(i, j int) bool {
+		return m.fitnesses[indices[i]] > m.fitnesses[indices[j]]
+	})
+	return indices
+}
+
+// randomGenotype draws each gene uniformly from its bounds
+func (m *PopulationManager) randomGenotype() Float32Genotype {
+	floats := make([]float32, len(m.config.GeneBounds))
+	for i, bounds := range m.config.GeneBounds {
+		floats[i] = bounds.Min + m.rand.Float32()*(bounds.Max-bounds.Min)
+	}
+	return NewFloat32Genotype(floats)
+}
@@ -1 +1,96 @@
 package evolution
+
+import "math/rand"
+
+// SelectionStrategy chooses which genotypes get to be parents
+type SelectionStrategy interface {
+	// selectParent returns the index of the chosen parent
+	selectParent(fitnesses []float32, rand *rand.Rand) int
+}
+
+// TournamentSelection picks the fittest of size randomly chosen genotypes
+type TournamentSelection struct {
+	size int
+}
+
+// NewTournamentSelection returns an instance of the TournamentSelection strategy
+func NewTournamentSelection(size int) TournamentSelection {
+	if size < 1 {
+		panic("Tournament size must be positive.")
+	}
+	return TournamentSelection{size: size}
+}
+
+func (t TournamentSelection) selectParent(fitnesses []float32, rand *rand.Rand) int {
+	winner := rand.Intn(len(fitnesses))
+	for i := 1; i < t.size; i++ {
+		contender := rand.Intn(len(fitnesses))
+		if fitnesses[contender] > fitnesses[winner] {
+			winner = contender
+		}
+	}
+	return winner
+}
+
+// RouletteSelection picks genotypes with probability proportional to their fitness
+type RouletteSelection struct{}
+
+// NewRouletteSelection returns an instance of the RouletteSelection strategy
+func NewRouletteSelection() RouletteSelection {
+	return RouletteSelection{}
+}
+
+// selectParent falls back to a uniform choice if every fitness is 0
+func (r RouletteSelection) selectParent(fitnesses []float32, rand *rand.Rand) int {
+	total := float32(0)
+	for _, fitness := range fitnesses {
+		total += fitness
+	}
+	if total <= 0 {
+		return rand.Intn(len(fitnesses))
+	}
+	spin := rand.Float32() * total
+	for i, fitness := range fitnesses {
+		spin -= fitness
+		if spin < 0 {
+			return i
+		}
+	}
+	// rounding error can leave spin just above 0
+	return len(fitnesses) - 1
+}
+
+// crossover recombines the parents with uniform crossover with probability CrossoverRate.
+// Otherwise the children are copies of the parents.
+func (m *PopulationManager) crossover(parent1, parent2 Float32Genotype) (Float32Genotype, Float32Genotype) {
+	child1 := parent1.MakeCopy()
+	child2 := parent2.MakeCopy()
+	if m.rand.Float32() >= m.config.CrossoverRate {
+		return child1, child2
+	}
+	for i := range child1.floats {
+		if m.rand.Intn(2) == 0 {
+			child1.floats[i], child2.floats[i] = child2.floats[i], child1.floats[i]
+		}
+	}
+	return child1, child2
+}
+
+// mutate adds Gaussian noise to each gene with probability MutationRate
+// and keeps the result within the gene's bounds
+func (m *PopulationManager) mutate(genotype Float32Genotype) Float32Genotype {
+	for i, bounds := range m.config.GeneBounds {
+		if m.rand.Float32() >= m.config.MutationRate {
+			continue
+		}
+		stdDev := m.config.MutationScale * (bounds.Max - bounds.Min)
+		gene := genotype.floats[i] + float32(m.rand.NormFloat64())*stdDev
+		if gene < bounds.Min {
+			gene = bounds.Min
+		} else if gene > bounds.Max {
+			gene = bounds.Max
+		}
+		genotype.floats[i] = gene
+	}
+	return genotype
+}
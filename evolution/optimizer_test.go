@@ -0,0 +1,109 @@
+package evolution
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+// targetCalculator rates genotypes by how close they are to target
+type targetCalculator struct {
+	target []float32
+}
+
+func (c targetCalculator) CalculateFitness(genotype Float32Genotype) float32 {
+	distance := float64(0)
+	for i, value := range c.target {
+		diff := float64(genotype.Get(i) - value)
+		distance += diff * diff
+	}
+	return float32(1 / (1 + math.Sqrt(distance)))
+}
+
+func makeConfig(selection SelectionStrategy) Config {
+	return Config{
+		PopulationSize: 30,
+		GeneBounds:     []Bounds{{0, 10}, {0, 10}, {0, 1}, {0, 1}},
+		Selection:      selection,
+		CrossoverRate:  0.7,
+		MutationRate:   0.2,
+		MutationScale:  0.1,
+		NumElites:      2,
+		Seed:           17,
+	}
+}
+
+func TestRunImprovesFitness(t *testing.T) {
+	calculator := targetCalculator{target: []float32{3, 7, .25, .75}}
+	for _, selection := range []SelectionStrategy{NewTournamentSelection(3), NewRouletteSelection()} {
+		manager := NewPopulationManager(makeConfig(selection))
+		best, history := manager.Run(calculator, 40)
+		if len(history) != 40 {
+			t.Fatalf("Expected 40 generations of history, found %d", len(history))
+		}
+		if history[39].BestFitness < history[0].BestFitness {
+			t.Errorf("Best fitness fell from %f to %f", history[0].BestFitness, history[39].BestFitness)
+		}
+		if calculator.CalculateFitness(best) < .5 {
+			t.Errorf("Expected best genotype to be near the target, fitness was %f",
+				calculator.CalculateFitness(best))
+		}
+	}
+}
+
+func TestElitismKeepsBest(t *testing.T) {
+	calculator := targetCalculator{target: []float32{5, 5, .5, .5}}
+	manager := NewPopulationManager(makeConfig(NewTournamentSelection(2)))
+	_, history := manager.Run(calculator, 20)
+	for i := 1; i < len(history); i++ {
+		if history[i].BestFitness < history[i-1].BestFitness {
+			t.Errorf("(generation %d) Best fitness fell from %f to %f despite elitism",
+				i, history[i-1].BestFitness, history[i].BestFitness)
+		}
+	}
+}
+
+func TestGenesStayInBounds(t *testing.T) {
+	config := makeConfig(NewRouletteSelection())
+	config.MutationRate = 1
+	config.MutationScale = 2
+	manager := NewPopulationManager(config)
+	manager.Run(targetCalculator{target: []float32{0, 0, 0, 0}}, 10)
+	for _, genotype := range manager.Population() {
+		for i, bounds := range config.GeneBounds {
+			if genotype.Get(i) < bounds.Min || genotype.Get(i) > bounds.Max {
+				t.Errorf("Gene %d is %f, outside of [%f, %f]", i, genotype.Get(i), bounds.Min, bounds.Max)
+			}
+		}
+	}
+}
+
+func TestInitialPopulationIsUsed(t *testing.T) {
+	config := makeConfig(NewTournamentSelection(2))
+	config.InitialPopulation = []Float32Genotype{NewFloat32Genotype([]float32{1, 9, .7, .01})}
+	manager := NewPopulationManager(config)
+	first := manager.Population()[0]
+	if first.Get(1) != 9 {
+		t.Errorf("Expected the first genotype to come from InitialPopulation, found %v", first.floats)
+	}
+}
+
+// reseedRecorder is a targetCalculator that writes down the streams it is reseeded with
+type reseedRecorder struct {
+	targetCalculator
+	streams *[]int
+}
+
+func (r reseedRecorder) Reseed(stream int) FitnessCalculator {
+	*r.streams = append(*r.streams, stream)
+	return r.targetCalculator
+}
+
+func TestEachGenerationIsReseeded(t *testing.T) {
+	streams := make([]int, 0)
+	manager := NewPopulationManager(makeConfig(NewTournamentSelection(2)))
+	manager.Run(reseedRecorder{targetCalculator{target: []float32{5, 5, .5, .5}}, &streams}, 4)
+	if !reflect.DeepEqual(streams, []int{0, 1, 2, 3}) {
+		t.Errorf("Expected each generation to get its own stream, found %v", streams)
+	}
+}
@@ -0,0 +1,59 @@
+package fileio
+
+import (
+	"io"
+	"math"
+	"os"
+	"strings"
+
+	"github.com/GaudiestTooth17/infection-resistant-network/diseasednetwork"
+)
+
+// ReadNodeAttributes reads the attributes of a network's nodes from a file.
+// See ParseNodeAttributes for the format.
+func ReadNodeAttributes(fileName string, numNodes int) ([]diseasednetwork.NodeAttributes, error) {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return ParseNodeAttributes(file, fileName, numNodes)
+}
+
+// ParseNodeAttributes reads one node per line as node, susceptibility, infectiousness and group.
+// Lines that are blank or don't start with a digit, such as a header, are skipped.
+// Nodes that aren't listed get the default attributes.
+// If there are problems, the attributes hold every line that could be read and the error
+// is an ErrorList. name is only used in error messages.
+func ParseNodeAttributes(r io.Reader, name string, numNodes int) ([]diseasednetwork.NodeAttributes, error) {
+	reader := newLineReader(r)
+	errs := ErrorList{}
+	attributes := diseasednetwork.DefaultAttributes(numNodes)
+
+	for line, ok := reader.next(); ok; line, ok = reader.next() {
+		line = strings.TrimSpace(line)
+		if line == "" || line[0] < '0' || line[0] > '9' {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 4 {
+			errs.add(name, reader.lineNum, "node attributes", line, ErrFieldCount)
+			continue
+		}
+		node, nodeOK := parseInt(&errs, name, reader.lineNum, "node", fields[0], 0, numNodes-1)
+		sus, susOK := parseFloat(&errs, name, reader.lineNum, "susceptibility", fields[1], 0, math.MaxFloat32)
+		inf, infOK := parseFloat(&errs, name, reader.lineNum, "infectiousness", fields[2], 0, math.MaxFloat32)
+		group, groupOK := parseInt(&errs, name, reader.lineNum, "group", fields[3], math.MinInt32, math.MaxInt32)
+		if nodeOK && susOK && infOK && groupOK {
+			attributes[node] = diseasednetwork.NodeAttributes{
+				Susceptibility: float32(sus),
+				Infectiousness: float32(inf),
+				Group:          group,
+			}
+		}
+	}
+	if reader.err() != nil {
+		return attributes, reader.err()
+	}
+	return attributes, errs.err()
+}
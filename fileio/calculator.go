@@ -0,0 +1,66 @@
+package fileio
+
+import (
+	"math"
+	"os"
+	"strings"
+	"unicode"
+
+	"github.com/GaudiestTooth17/infection-resistant-network/optimized"
+)
+
+// ReadFitnessCalculator reads a text file containing a text description of a fitness calculator
+// and the network it should use.
+// This function is deprecated. Sim length and number of sims are now given as cmd line parameters.
+func ReadFitnessCalculator(fitnessCalcFilename, adjListFilename string, seed int64) (optimized.NetworkFitnessCalculator, error) {
+	errs := ErrorList{}
+	network, err := ReadAdjacencyList(adjListFilename)
+	if list, ok := err.(ErrorList); ok {
+		errs = append(errs, list...)
+	} else if err != nil {
+		return optimized.NetworkFitnessCalculator{}, err
+	}
+
+	fitnessCalcFile, err := os.Open(fitnessCalcFilename)
+	if err != nil {
+		return optimized.NetworkFitnessCalculator{}, err
+	}
+	defer fitnessCalcFile.Close()
+	reader := newLineReader(fitnessCalcFile)
+
+	// there are three values that need to be retrieved: numTrials, simLength and the disease.
+	// it will ignore any labels, but they should be used for clarity!
+	// skip over the plaintext header containing the labels
+	line, ok := reader.next()
+	for ok && (line == "" || !unicode.IsDigit(rune(line[0]))) {
+		line, ok = reader.next()
+	}
+
+	fields := []string{"numTrials", "simLength", "disease"}
+	values := make([]string, 0, len(fields))
+	lineNums := make([]int, 0, len(fields))
+	for ; ok && len(values) < len(fields); line, ok = reader.next() {
+		values = append(values, line)
+		lineNums = append(lineNums, reader.lineNum)
+	}
+	if reader.err() != nil {
+		return optimized.NetworkFitnessCalculator{}, reader.err()
+	}
+	if len(values) < len(fields) {
+		errs.add(fitnessCalcFilename, reader.lineNum+1, fields[len(values)], "", ErrMissing)
+		return optimized.NetworkFitnessCalculator{}, errs
+	}
+
+	numTrials, _ := parseInt(&errs, fitnessCalcFilename, lineNums[0], fields[0],
+		strings.TrimSpace(values[0]), 1, math.MaxInt32)
+	simLength, _ := parseInt(&errs, fitnessCalcFilename, lineNums[1], fields[1],
+		strings.TrimSpace(values[1]), 0, math.MaxInt32)
+	disease := parseDiseaseLine(&errs, fitnessCalcFilename, lineNums[2], values[2])
+	if disease != nil && network.NumNodes() > 0 {
+		checkDiseaseFits(&errs, fitnessCalcFilename, lineNums[2], disease, network.NumNodes())
+	}
+	if len(errs) > 0 {
+		return optimized.NetworkFitnessCalculator{}, errs
+	}
+	return optimized.NewNetworkFitnessCalculator(network, numTrials, simLength, disease, seed), nil
+}
@@ -0,0 +1,316 @@
+package fileio
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/GaudiestTooth17/infection-resistant-network/diseasednetwork"
+)
+
+// ReadDisease reads a disease from a file. See ParseDisease for the format.
+func ReadDisease(fileName string) (diseasednetwork.Disease, error) {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return ParseDisease(file, fileName)
+}
+
+// ParseDisease reads a disease from the first line of r. The line holds four values:
+// timeToI, timeToR, infectionProbability and how to infect nodes at the start.
+// timeToI and timeToR are durations. A whole number of steps means every node takes exactly
+// that long. Otherwise they can be written without spaces as one of these distributions,
+// where mean is the average number of steps:
+//
+//	geometric(mean)
+//	poisson(mean)
+//	gamma(shape,mean)
+//	erlang(k,mean)
+//	empirical(w0,w1,w2,...) where wk is the relative frequency of lasting k steps
+//
+// The initial infection is a whole number of random nodes to infect or one of these strategies:
+//
+//	fraction(f)                   a random fraction f of the nodes
+//	degree(n)                     the n nodes with the most neighbors
+//	cluster(n)                    n nodes around a random node
+//	group(g,n)                    n random nodes from group g of the node attributes
+//	staggered(n,interval,waves)   n random nodes every interval steps, waves times in total
+//	nodes(file)                   the nodes listed in file, which is relative to the disease file
+//
+// The disease is nil if there are any problems. name is only used in error messages.
+func ParseDisease(r io.Reader, name string) (diseasednetwork.Disease, error) {
+	reader := newLineReader(r)
+	line, ok := reader.next()
+	if !ok {
+		if reader.err() != nil {
+			return nil, reader.err()
+		}
+		return nil, ErrorList{{File: name, Line: 1, Field: "disease", Err: ErrMissing}}
+	}
+	errs := ErrorList{}
+	disease := parseDiseaseLine(&errs, name, reader.lineNum, line)
+	return disease, errs.err()
+}
+
+// CheckDisease makes sure a disease read from fileName can be used on a network with numNodes
+// nodes. Nodes listed by nodes(file) can't be checked until the network has been read, so call
+// this after reading both. Problems are returned as an ErrorList.
+func CheckDisease(disease diseasednetwork.Disease, fileName string, numNodes int) error {
+	errs := ErrorList{}
+	checkDiseaseFits(&errs, fileName, 1, disease, numNodes)
+	return errs.err()
+}
+
+// checkDiseaseFits adds a problem to errs if the disease's initial infection can't be applied
+// to a network with numNodes nodes
+func checkDiseaseFits(errs *ErrorList, file string, lineNum int, disease diseasednetwork.Disease, numNodes int) {
+	strategy, ok := disease.InitialInfection().(diseasednetwork.ValidatedStrategy)
+	if !ok {
+		return
+	}
+	if err := strategy.Validate(numNodes); err != nil {
+		errs.add(file, lineNum, "numberToInfectAtStart", "", fmt.Errorf("%w: %v", ErrOutOfRange, err))
+	}
+}
+
+// parseDiseaseLine reads the disease parameters from line. It returns nil if there are any problems.
+func parseDiseaseLine(errs *ErrorList, file string, lineNum int, line string) diseasednetwork.Disease {
+	fields := strings.Fields(line)
+	if len(fields) != 4 {
+		errs.add(file, lineNum, "disease", line, ErrFieldCount)
+		return nil
+	}
+	numErrs := len(*errs)
+	timeToI := parseDuration(errs, file, lineNum, "timeToI", fields[0])
+	timeToR := parseDuration(errs, file, lineNum, "timeToR", fields[1])
+	infectionProbability, _ := parseFloat(errs, file, lineNum, "infectionProbability", fields[2], 0, 1)
+	infectionStrategy := parseInfectionStrategy(errs, file, lineNum, fields[3])
+	if len(*errs) > numErrs {
+		return nil
+	}
+	return diseasednetwork.NewBasicDiseaseWithDurations(timeToI, timeToR, float32(infectionProbability),
+		infectionStrategy)
+}
+
+// parseDuration reads a fixed number of steps or a duration distribution. See ParseDisease
+// for the format. It returns nil if there are any problems.
+func parseDuration(errs *ErrorList, file string, lineNum int, field, value string) diseasednetwork.DurationDistribution {
+	if !strings.Contains(value, "(") {
+		steps, ok := parseInt(errs, file, lineNum, field, value, 0, math.MaxInt16)
+		if !ok {
+			return nil
+		}
+		return diseasednetwork.NewFixedDuration(int16(steps))
+	}
+	distribution, params, ok := splitCall(value)
+	if !ok {
+		errs.add(file, lineNum, field, value, ErrSyntax)
+		return nil
+	}
+
+	// each distribution checks its number of parameters and reads them with these
+	numErrs := len(*errs)
+	wantParams := func(n int) bool {
+		if len(params) != n {
+			errs.add(file, lineNum, field, value, ErrFieldCount)
+			return false
+		}
+		return true
+	}
+	param := func(i int, name string, min float64) float64 {
+		f, _ := parseFloat(errs, file, lineNum, field+" "+name, params[i], min, math.MaxInt16)
+		return f
+	}
+
+	switch distribution {
+	case "geometric":
+		if wantParams(1) {
+			mean := param(0, "mean", 0)
+			if len(*errs) == numErrs {
+				return diseasednetwork.NewGeometricDuration(mean)
+			}
+		}
+	case "poisson":
+		if wantParams(1) {
+			mean := param(0, "mean", 0)
+			if len(*errs) == numErrs {
+				return diseasednetwork.NewPoissonDuration(mean)
+			}
+		}
+	case "gamma":
+		if wantParams(2) {
+			shape := param(0, "shape", math.SmallestNonzeroFloat64)
+			mean := param(1, "mean", 0)
+			if len(*errs) == numErrs {
+				return diseasednetwork.NewGammaDuration(shape, mean)
+			}
+		}
+	case "erlang":
+		if wantParams(2) {
+			k, _ := parseInt(errs, file, lineNum, field+" k", params[0], 1, math.MaxInt16)
+			mean := param(1, "mean", 0)
+			if len(*errs) == numErrs {
+				return diseasednetwork.NewErlangDuration(k, mean)
+			}
+		}
+	case "empirical":
+		if len(params) > math.MaxInt16+1 {
+			errs.add(file, lineNum, field, value, ErrFieldCount)
+			return nil
+		}
+		weights := make([]float64, len(params))
+		total := 0.0
+		for i := range params {
+			weights[i] = param(i, "weight "+strconv.Itoa(i), 0)
+			total += weights[i]
+		}
+		if len(*errs) > numErrs {
+			return nil
+		}
+		if total == 0 {
+			errs.add(file, lineNum, field, value, ErrOutOfRange)
+			return nil
+		}
+		return diseasednetwork.NewEmpiricalDuration(weights)
+	default:
+		errs.add(file, lineNum, field, distribution, ErrUnknown)
+	}
+	return nil
+}
+
+// parseInfectionStrategy reads a number of random nodes to infect at the start or one of the
+// strategies listed in ParseDisease
+func parseInfectionStrategy(errs *ErrorList, file string, lineNum int, value string) diseasednetwork.InitialInfectionStrategy {
+	const field = "numberToInfectAtStart"
+	if !strings.Contains(value, "(") {
+		n, ok := parseInt(errs, file, lineNum, field, value, 0, math.MaxInt32)
+		if !ok {
+			return nil
+		}
+		return diseasednetwork.NewInfectN(n)
+	}
+	strategy, params, ok := splitCall(value)
+	if !ok {
+		errs.add(file, lineNum, field, value, ErrSyntax)
+		return nil
+	}
+
+	numErrs := len(*errs)
+	wantParams := func(n int) bool {
+		if len(params) != n {
+			errs.add(file, lineNum, field, value, ErrFieldCount)
+			return false
+		}
+		return true
+	}
+	param := func(i int, name string, min int) int {
+		n, _ := parseInt(errs, file, lineNum, field+" "+name, params[i], min, math.MaxInt32)
+		return n
+	}
+
+	switch strategy {
+	case "fraction":
+		if wantParams(1) {
+			fraction, _ := parseFloat(errs, file, lineNum, field+" fraction", params[0], 0, 1)
+			if len(*errs) == numErrs {
+				return diseasednetwork.NewInfectFraction(fraction)
+			}
+		}
+	case "degree":
+		if wantParams(1) {
+			n := param(0, "n", 0)
+			if len(*errs) == numErrs {
+				return diseasednetwork.NewInfectHighestDegree(n)
+			}
+		}
+	case "cluster":
+		if wantParams(1) {
+			n := param(0, "n", 0)
+			if len(*errs) == numErrs {
+				return diseasednetwork.NewInfectCluster(n)
+			}
+		}
+	case "group":
+		if wantParams(2) {
+			group := param(0, "group", math.MinInt32)
+			n := param(1, "n", 0)
+			if len(*errs) == numErrs {
+				return diseasednetwork.NewInfectGroup(group, n)
+			}
+		}
+	case "staggered":
+		if wantParams(3) {
+			n := param(0, "n", 0)
+			interval := param(1, "interval", 1)
+			numWaves := param(2, "waves", 0)
+			if len(*errs) == numErrs {
+				return diseasednetwork.NewStaggeredInfection(n, uint(interval), numWaves)
+			}
+		}
+	case "nodes":
+		if wantParams(1) {
+			nodes, err := ReadNodeList(relativeTo(file, params[0]))
+			if err != nil {
+				errs.add(file, lineNum, field+" nodes", params[0], err)
+				return nil
+			}
+			return diseasednetwork.NewInfectNodes(nodes...)
+		}
+	default:
+		errs.add(file, lineNum, field, strategy, ErrUnknown)
+	}
+	return nil
+}
+
+// splitCall splits a value written like name(a,b,c) into its name and parameters
+func splitCall(value string) (name string, params []string, ok bool) {
+	open := strings.Index(value, "(")
+	if open < 0 || !strings.HasSuffix(value, ")") {
+		return "", nil, false
+	}
+	return value[:open], strings.Split(value[open+1:len(value)-1], ","), true
+}
+
+// relativeTo interprets a path found in file as relative to the directory file is in
+func relativeTo(file, path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(filepath.Dir(file), path)
+}
+
+// ReadNodeList reads node IDs from a file. See ParseNodeList for the format.
+func ReadNodeList(fileName string) ([]int, error) {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return ParseNodeList(file, fileName)
+}
+
+// ParseNodeList reads node IDs separated by spaces or new lines. If there are problems, the
+// list holds every node that could be read and the error is an ErrorList.
+// name is only used in error messages.
+func ParseNodeList(r io.Reader, name string) ([]int, error) {
+	reader := newLineReader(r)
+	errs := ErrorList{}
+	nodes := make([]int, 0)
+	for line, ok := reader.next(); ok; line, ok = reader.next() {
+		for _, field := range strings.Fields(line) {
+			if node, ok := parseInt(&errs, name, reader.lineNum, "node", field, 0, math.MaxInt32); ok {
+				nodes = append(nodes, node)
+			}
+		}
+	}
+	if reader.err() != nil {
+		return nodes, reader.err()
+	}
+	return nodes, errs.err()
+}
@@ -0,0 +1,76 @@
+// Package fileio reads the text files that describe networks, diseases, genotypes and
+// fitness calculators. Instead of panicking on bad input, the readers keep going and
+// return an ErrorList with a ParseError for every problem they find.
+package fileio
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// These are the reasons a field can be rejected. Use errors.Is to check for them.
+var (
+	// ErrFieldCount means a line has the wrong number of fields
+	ErrFieldCount = errors.New("wrong number of fields")
+	// ErrOutOfRange means a value was read successfully but isn't allowed
+	ErrOutOfRange = errors.New("value out of range")
+	// ErrMissing means the file ended before a required line
+	ErrMissing = errors.New("missing line")
+	// ErrSyntax means a value isn't written in a form that can be read
+	ErrSyntax = errors.New("invalid syntax")
+	// ErrUnknown means a name doesn't match any of the options
+	ErrUnknown = errors.New("unknown name")
+)
+
+// ParseError describes a problem with one field in an input file
+type ParseError struct {
+	File string
+	// Line is the 1 based line number of the problem or 0 if it isn't tied to a line
+	Line int
+	// Field is the name of the value that couldn't be read
+	Field string
+	// Value is the text that was found
+	Value string
+	Err   error
+}
+
+func (e *ParseError) Error() string {
+	location := e.File
+	if e.Line > 0 {
+		location = fmt.Sprintf("%s:%d", e.File, e.Line)
+	}
+	if e.Value == "" {
+		return fmt.Sprintf("%s: %s: %v", location, e.Field, e.Err)
+	}
+	return fmt.Sprintf("%s: %s %q: %v", location, e.Field, e.Value, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// ErrorList holds every problem found in a file
+type ErrorList []*ParseError
+
+// Error puts each problem on its own line
+func (l ErrorList) Error() string {
+	messages := make([]string, len(l))
+	for i, err := range l {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "\n")
+}
+
+// add records a problem
+func (l *ErrorList) add(file string, line int, field, value string, err error) {
+	*l = append(*l, &ParseError{File: file, Line: line, Field: field, Value: value, Err: err})
+}
+
+// err returns nil if there were no problems so that an empty list isn't mistaken for an error
+func (l ErrorList) err() error {
+	if len(l) == 0 {
+		return nil
+	}
+	return l
+}
@@ -0,0 +1,61 @@
+package fileio
+
+import (
+	"bufio"
+	"io"
+	"math"
+	"strconv"
+)
+
+// lineReader reads a file one line at a time and keeps track of the line number
+type lineReader struct {
+	scanner *bufio.Scanner
+	lineNum int
+}
+
+func newLineReader(r io.Reader) *lineReader {
+	return &lineReader{scanner: bufio.NewScanner(r)}
+}
+
+// next returns the next line. ok is false at the end of the file.
+func (r *lineReader) next() (line string, ok bool) {
+	if !r.scanner.Scan() {
+		return "", false
+	}
+	r.lineNum++
+	return r.scanner.Text(), true
+}
+
+// err returns the error that stopped the reader, if it wasn't the end of the file
+func (r *lineReader) err() error {
+	return r.scanner.Err()
+}
+
+// parseInt reads an int that must be in [min, max]. Problems are added to errs.
+func parseInt(errs *ErrorList, file string, line int, field, value string, min, max int) (int, bool) {
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		errs.add(file, line, field, value, err)
+		return 0, false
+	}
+	if n < min || n > max {
+		errs.add(file, line, field, value, ErrOutOfRange)
+		return 0, false
+	}
+	return n, true
+}
+
+// parseFloat reads a float that must be in [min, max], so NaN is never allowed.
+// Problems are added to errs.
+func parseFloat(errs *ErrorList, file string, line int, field, value string, min, max float64) (float64, bool) {
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		errs.add(file, line, field, value, err)
+		return 0, false
+	}
+	if math.IsNaN(f) || f < min || f > max {
+		errs.add(file, line, field, value, ErrOutOfRange)
+		return 0, false
+	}
+	return f, true
+}
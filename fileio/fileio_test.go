@@ -0,0 +1,274 @@
+package fileio
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/GaudiestTooth17/infection-resistant-network/diseasednetwork"
+)
+
+// parseErrors checks that err is an ErrorList and returns it
+func parseErrors(t *testing.T, err error) ErrorList {
+	t.Helper()
+	var list ErrorList
+	if !errors.As(err, &list) {
+		t.Fatalf("Expected an ErrorList, got %v", err)
+	}
+	return list
+}
+
+func TestParseAdjacencyList(t *testing.T) {
+	input := "4\n0 1\n1 2 5\n2 3\n\n0 0 0\n"
+	network, err := ParseAdjacencyList(strings.NewReader(input), "net.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if network.NumNodes() != 4 {
+		t.Errorf("Expected 4 nodes, found %d", network.NumNodes())
+	}
+	if !network.HasEdge(1, 2) || !network.HasEdge(2, 1) {
+		t.Error("Expected an undirected edge between 1 and 2")
+	}
+	if network.EdgeWeight(1, 2) != 5 || network.EdgeWeight(0, 1) != 1 {
+		t.Errorf("Expected weights of 5 and the default of 1, found %d and %d",
+			network.EdgeWeight(1, 2), network.EdgeWeight(0, 1))
+	}
+	if network.HasEdge(0, 0) {
+		t.Error("Coordinates after the blank line were read as edges")
+	}
+}
+
+func TestAdjacencyListErrors(t *testing.T) {
+	input := "3\n0 1\n1 x\n0 7\n1 2 3 4\n1 2\n0 2 0\n"
+	network, err := ParseAdjacencyList(strings.NewReader(input), "net.txt")
+	list := parseErrors(t, err)
+	if len(list) != 4 {
+		t.Fatalf("Expected 4 problems, found %d:\n%v", len(list), err)
+	}
+	expectedLines := []int{3, 4, 5, 7}
+	expectedFields := []string{"to node", "to node", "edge", "weight"}
+	for i, parseErr := range list {
+		if parseErr.Line != expectedLines[i] || parseErr.Field != expectedFields[i] {
+			t.Errorf("Expected problem with %s on line %d, found %v",
+				expectedFields[i], expectedLines[i], parseErr)
+		}
+	}
+	var numErr *strconv.NumError
+	if !errors.As(list[0], &numErr) {
+		t.Errorf("Expected a strconv.NumError for a node that isn't a number, found %v", list[0].Err)
+	}
+	if !errors.Is(list[1], ErrOutOfRange) {
+		t.Errorf("Expected ErrOutOfRange for a node that doesn't exist, found %v", list[1].Err)
+	}
+	if !errors.Is(list[2], ErrFieldCount) {
+		t.Errorf("Expected ErrFieldCount for an edge with 4 fields, found %v", list[2].Err)
+	}
+	if !network.HasEdge(0, 1) || !network.HasEdge(1, 2) {
+		t.Error("Valid edges should still be read when there are problems")
+	}
+}
+
+func TestParseNodeAttributes(t *testing.T) {
+	input := "node susceptibility infectiousness group\n0 0.5 2 1\n2 0 1 -3\n3 1 1\n1 x 1 0\n9 1 1 0\n"
+	attributes, err := ParseNodeAttributes(strings.NewReader(input), "attributes.txt", 4)
+	list := parseErrors(t, err)
+	expectedLines := []int{4, 5, 6}
+	expectedFields := []string{"node attributes", "susceptibility", "node"}
+	if len(list) != len(expectedLines) {
+		t.Fatalf("Expected %d problems, found %d:\n%v", len(expectedLines), len(list), err)
+	}
+	for i, parseErr := range list {
+		if parseErr.Line != expectedLines[i] || parseErr.Field != expectedFields[i] {
+			t.Errorf("Expected problem with %s on line %d, found %v",
+				expectedFields[i], expectedLines[i], parseErr)
+		}
+	}
+	if a := attributes[0]; a.Susceptibility != 0.5 || a.Infectiousness != 2 || a.Group != 1 {
+		t.Errorf("Node 0 was read as %+v", a)
+	}
+	if a := attributes[2]; a.Susceptibility != 0 || a.Group != -3 {
+		t.Errorf("Node 2 was read as %+v", a)
+	}
+	for _, node := range []int{1, 3} {
+		if a := attributes[node]; a.Susceptibility != 1 || a.Infectiousness != 1 || a.Group != 0 {
+			t.Errorf("Node %d should have the default attributes, found %+v", node, a)
+		}
+	}
+}
+
+func TestParseImportationSchedule(t *testing.T) {
+	input := "step count\n1 5\n3 2\n3 1\n0 4\n7\n"
+	schedule, err := ParseImportationSchedule(strings.NewReader(input), "imports.csv")
+	list := parseErrors(t, err)
+	if len(list) != 2 || list[0].Line != 5 || list[0].Field != "step" || list[1].Line != 6 {
+		t.Errorf("Expected problems with lines 5 and 6, found:\n%v", err)
+	}
+	expected := map[uint]int{1: 5, 3: 3}
+	if !reflect.DeepEqual(schedule, expected) {
+		t.Errorf("Expected %v, found %v", expected, schedule)
+	}
+}
+
+func TestParseDisease(t *testing.T) {
+	disease, err := ParseDisease(strings.NewReader("4 7 .02 10\n"), "disease.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if disease.InfectionProbability() != .02 {
+		t.Errorf("Expected infection probability .02, found %f", disease.InfectionProbability())
+	}
+}
+
+func TestParseInfectionStrategies(t *testing.T) {
+	dir := t.TempDir()
+	if err := ioutil.WriteFile(filepath.Join(dir, "seeds.txt"), []byte("3 5\n8\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	strategies := map[string]diseasednetwork.InitialInfectionStrategy{
+		"10":               diseasednetwork.NewInfectN(10),
+		"fraction(.1)":     diseasednetwork.NewInfectFraction(.1),
+		"degree(4)":        diseasednetwork.NewInfectHighestDegree(4),
+		"cluster(6)":       diseasednetwork.NewInfectCluster(6),
+		"group(-2,3)":      diseasednetwork.NewInfectGroup(-2, 3),
+		"staggered(2,5,4)": diseasednetwork.NewStaggeredInfection(2, 5, 4),
+		"nodes(seeds.txt)": diseasednetwork.NewInfectNodes(3, 5, 8),
+	}
+	for value, expected := range strategies {
+		line := "4 7 .02 " + value + "\n"
+		disease, err := ParseDisease(strings.NewReader(line), filepath.Join(dir, "disease.txt"))
+		if err != nil {
+			t.Errorf("%s: %v", value, err)
+			continue
+		}
+		if !reflect.DeepEqual(disease.InitialInfection(), expected) {
+			t.Errorf("Expected %s to be read as %+v, found %+v", value, expected, disease.InitialInfection())
+		}
+		if err := CheckDisease(disease, "disease.txt", 9); err != nil {
+			t.Errorf("Expected %s to fit a network of 9 nodes, found %v", value, err)
+		}
+	}
+
+	// the seed list can only be checked once the size of the network is known
+	disease, err := ParseDisease(strings.NewReader("4 7 .02 nodes(seeds.txt)\n"), filepath.Join(dir, "disease.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	list := parseErrors(t, CheckDisease(disease, "disease.txt", 8))
+	if len(list) != 1 || !errors.Is(list[0], ErrOutOfRange) || list[0].Line != 1 {
+		t.Errorf("Expected node 8 to be out of range in a network of 8 nodes, found %v", list)
+	}
+
+	problems := map[string]error{
+		"group(1)":        ErrFieldCount,
+		"outbreak(1)":     ErrUnknown,
+		"fraction(2)":     ErrOutOfRange,
+		"staggered(1,0,3": ErrSyntax,
+		"nodes(none.txt)": os.ErrNotExist,
+	}
+	for value, expected := range problems {
+		line := "4 7 .02 " + value + "\n"
+		_, err := ParseDisease(strings.NewReader(line), filepath.Join(dir, "disease.txt"))
+		list := parseErrors(t, err)
+		if len(list) != 1 || !errors.Is(list[0], expected) {
+			t.Errorf("Expected %v from %s, found:\n%v", expected, value, err)
+		}
+	}
+}
+
+func TestDiseaseErrors(t *testing.T) {
+	// the number of nodes to infect used to be read without checking for an error
+	_, err := ParseDisease(strings.NewReader("4 7 .02 ten\n"), "disease.txt")
+	list := parseErrors(t, err)
+	if len(list) != 1 || list[0].Field != "numberToInfectAtStart" || list[0].Line != 1 {
+		t.Errorf("Expected a problem with numberToInfectAtStart on line 1, found:\n%v", err)
+	}
+
+	disease, err := ParseDisease(strings.NewReader("-1 7 1.5 10\n"), "disease.txt")
+	list = parseErrors(t, err)
+	if disease != nil {
+		t.Error("Expected no disease when there are problems")
+	}
+	if len(list) != 2 {
+		t.Fatalf("Expected 2 problems, found %d:\n%v", len(list), err)
+	}
+	for _, parseErr := range list {
+		if !errors.Is(parseErr, ErrOutOfRange) {
+			t.Errorf("Expected ErrOutOfRange, found %v", parseErr)
+		}
+	}
+
+	for _, line := range []string{"1 1 NaN 1", "geometric(NaN) 1 .5 1"} {
+		_, err = ParseDisease(strings.NewReader(line+"\n"), "disease.txt")
+		list = parseErrors(t, err)
+		if len(list) != 1 || !errors.Is(list[0], ErrOutOfRange) {
+			t.Errorf("Expected NaN in %q to be out of range, found:\n%v", line, err)
+		}
+	}
+
+	_, err = ParseDisease(strings.NewReader("4 7 .02\n"), "disease.txt")
+	if !errors.Is(parseErrors(t, err)[0], ErrFieldCount) {
+		t.Errorf("Expected ErrFieldCount, found %v", err)
+	}
+
+	_, err = ParseDisease(strings.NewReader(""), "disease.txt")
+	if !errors.Is(parseErrors(t, err)[0], ErrMissing) {
+		t.Errorf("Expected ErrMissing for an empty file, found %v", err)
+	}
+}
+
+func TestParseGenotypeConf(t *testing.T) {
+	input := "minConnections,maxConnections,removeInfectedNeighborProb,addNeighborOfNeighborProb\n" +
+		"1,30,0.7,0.01\n" +
+		"2,20,0.5\n" +
+		"3,10,abc,0.2\n" +
+		"4,40,0.1,0.3\n"
+	genotypes, err := ParseGenotypeConf(strings.NewReader(input), "genotypes.csv")
+	list := parseErrors(t, err)
+	if len(list) != 2 || list[0].Line != 3 || list[1].Line != 4 {
+		t.Errorf("Expected problems on lines 3 and 4, found:\n%v", err)
+	}
+	if len(genotypes) != 2 {
+		t.Fatalf("Expected the 2 valid genotypes, found %d", len(genotypes))
+	}
+	if genotypes[1].Get(1) != 40 {
+		t.Errorf("Expected maxConnections of 40, found %f", genotypes[1].Get(1))
+	}
+}
+
+func TestParseDurations(t *testing.T) {
+	valid := []string{"3", "geometric(4)", "poisson(2.5)", "gamma(2,6)", "erlang(3,9)", "empirical(0,1,2,1)"}
+	for _, duration := range valid {
+		_, err := ParseDisease(strings.NewReader(duration+" 7 .02 10\n"), "disease.txt")
+		if err != nil {
+			t.Errorf("Expected %s to be a valid duration, found %v", duration, err)
+		}
+	}
+
+	invalid := map[string]error{
+		"normal(4)":       ErrUnknown,
+		"poisson(4":       ErrSyntax,
+		"gamma(2)":        ErrFieldCount,
+		"gamma(0,4)":      ErrOutOfRange,
+		"geometric(-1)":   ErrOutOfRange,
+		"erlang(1.5,4)":   nil,
+		"empirical(0,0)":  ErrOutOfRange,
+		"empirical(1,-1)": ErrOutOfRange,
+	}
+	for duration, expected := range invalid {
+		disease, err := ParseDisease(strings.NewReader("2 "+duration+" .02 10\n"), "disease.txt")
+		list := parseErrors(t, err)
+		if disease != nil || len(list) != 1 || !strings.HasPrefix(list[0].Field, "timeToR") {
+			t.Errorf("Expected one problem with timeToR for %s, found:\n%v", duration, err)
+			continue
+		}
+		if expected != nil && !errors.Is(list[0], expected) {
+			t.Errorf("Expected %v for %s, found %v", expected, duration, list[0])
+		}
+	}
+}
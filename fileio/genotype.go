@@ -0,0 +1,60 @@
+package fileio
+
+import (
+	"io"
+	"math"
+	"os"
+	"strings"
+	"unicode"
+
+	"github.com/GaudiestTooth17/infection-resistant-network/evolution"
+)
+
+// genotypeFields are the columns of a genotype file, in order
+var genotypeFields = []string{"minConnections", "maxConnections", "removeInfectedNeighborProb",
+	"addNeighborOfNeighborProb"}
+
+// ReadGenotypeConf reads genotypes from a csv file. See ParseGenotypeConf for the format.
+func ReadGenotypeConf(fileName string) ([]evolution.Float32Genotype, error) {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return ParseGenotypeConf(file, fileName)
+}
+
+// ParseGenotypeConf reads a csv with genotype values in it.
+// The column headers should be minConnections, maxConnections, removeInfectedNeighborProb, addNeighborOfNeighborProb.
+// The headers won't actually be read, but the data will be assigned in that order.
+// Lines that don't start with a digit are skipped. Only the rows without problems are returned.
+func ParseGenotypeConf(r io.Reader, name string) ([]evolution.Float32Genotype, error) {
+	reader := newLineReader(r)
+	errs := ErrorList{}
+	genotypes := make([]evolution.Float32Genotype, 0)
+	for line, ok := reader.next(); ok; line, ok = reader.next() {
+		if line == "" || !unicode.IsDigit(rune(line[0])) {
+			continue
+		}
+		numbers := strings.Split(line, ",")
+		if len(numbers) != len(genotypeFields) {
+			errs.add(name, reader.lineNum, "genotype", line, ErrFieldCount)
+			continue
+		}
+		floats := make([]float32, len(numbers))
+		rowOK := true
+		for i, number := range numbers {
+			f, ok := parseFloat(&errs, name, reader.lineNum, genotypeFields[i], strings.TrimSpace(number),
+				-math.MaxFloat32, math.MaxFloat32)
+			floats[i] = float32(f)
+			rowOK = rowOK && ok
+		}
+		if rowOK {
+			genotypes = append(genotypes, evolution.NewFloat32Genotype(floats))
+		}
+	}
+	if reader.err() != nil {
+		return genotypes, reader.err()
+	}
+	return genotypes, errs.err()
+}
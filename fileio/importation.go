@@ -0,0 +1,51 @@
+package fileio
+
+import (
+	"io"
+	"math"
+	"os"
+	"strings"
+)
+
+// ReadImportationSchedule reads the number of infections to import in each step from a file.
+// See ParseImportationSchedule for the format.
+func ReadImportationSchedule(fileName string) (map[uint]int, error) {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return ParseImportationSchedule(file, fileName)
+}
+
+// ParseImportationSchedule reads a time series with one step and the number of infections to
+// import in it on each line. Lines that are blank or don't start with a digit, such as a header,
+// are skipped. Steps that are listed more than once import the total of their counts.
+// If there are problems, the schedule holds every line that could be read and the error
+// is an ErrorList. name is only used in error messages.
+func ParseImportationSchedule(r io.Reader, name string) (map[uint]int, error) {
+	reader := newLineReader(r)
+	errs := ErrorList{}
+	schedule := make(map[uint]int)
+
+	for line, ok := reader.next(); ok; line, ok = reader.next() {
+		line = strings.TrimSpace(line)
+		if line == "" || line[0] < '0' || line[0] > '9' {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			errs.add(name, reader.lineNum, "importation", line, ErrFieldCount)
+			continue
+		}
+		step, stepOK := parseInt(&errs, name, reader.lineNum, "step", fields[0], 1, math.MaxInt32)
+		count, countOK := parseInt(&errs, name, reader.lineNum, "count", fields[1], 0, math.MaxInt32)
+		if stepOK && countOK {
+			schedule[uint(step)] += count
+		}
+	}
+	if reader.err() != nil {
+		return schedule, reader.err()
+	}
+	return schedule, errs.err()
+}
@@ -0,0 +1,72 @@
+package fileio
+
+import (
+	"io"
+	"math"
+	"os"
+	"strings"
+
+	"github.com/GaudiestTooth17/infection-resistant-network/diseasednetwork"
+)
+
+// ReadAdjacencyList reads a network from a file. See ParseAdjacencyList for the format.
+func ReadAdjacencyList(fileName string) (diseasednetwork.Network, error) {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return diseasednetwork.Network{}, err
+	}
+	defer file.Close()
+	return ParseAdjacencyList(file, fileName)
+}
+
+// ParseAdjacencyList reads a network with the first line being the number of nodes.
+// The next lines are edges with from node, to node and an optional weight between 1 and 255.
+// Edges without a weight get a weight of 1.
+// Then there may or may not be a blank line so that node coordinates can be recorded.
+// However, the coordinates are not read.
+// If there are problems, the network holds every edge that could be read and the error
+// is an ErrorList. name is only used in error messages.
+func ParseAdjacencyList(r io.Reader, name string) (diseasednetwork.Network, error) {
+	reader := newLineReader(r)
+	errs := ErrorList{}
+
+	// initialize network with number of nodes
+	line, ok := reader.next()
+	if !ok {
+		if reader.err() != nil {
+			return diseasednetwork.Network{}, reader.err()
+		}
+		errs.add(name, 1, "number of nodes", "", ErrMissing)
+		return diseasednetwork.Network{}, errs.err()
+	}
+	numNodes, nodesOK := parseInt(&errs, name, reader.lineNum, "number of nodes",
+		strings.TrimSpace(line), 0, math.MaxInt32)
+	network := diseasednetwork.NewNetwork(numNodes)
+
+	// populate network, stopping at the end of the file or a blank line
+	for line, ok = reader.next(); ok && strings.TrimSpace(line) != ""; line, ok = reader.next() {
+		fields := strings.Fields(line)
+		if len(fields) != 2 && len(fields) != 3 {
+			errs.add(name, reader.lineNum, "edge", line, ErrFieldCount)
+			continue
+		}
+		// without a valid number of nodes there is no range to check the nodes against
+		maxNode := math.MaxInt32
+		if nodesOK {
+			maxNode = numNodes - 1
+		}
+		i, iOK := parseInt(&errs, name, reader.lineNum, "from node", fields[0], 0, maxNode)
+		j, jOK := parseInt(&errs, name, reader.lineNum, "to node", fields[1], 0, maxNode)
+		weight, weightOK := 1, true
+		if len(fields) == 3 {
+			weight, weightOK = parseInt(&errs, name, reader.lineNum, "weight", fields[2], 1, math.MaxUint8)
+		}
+		if nodesOK && iOK && jOK && weightOK {
+			network.AddEdge(i, j, uint8(weight))
+		}
+	}
+	if reader.err() != nil {
+		return network, reader.err()
+	}
+	return network, errs.err()
+}
@@ -1,23 +1,74 @@
 package main
 
 import (
+	"flag"
 	"fmt"
+	"math"
 	"os"
 	"path"
 	"strconv"
 	"strings"
 	"time"
 
+	dsnet "github.com/GaudiestTooth17/infection-resistant-network/diseasednetwork"
+	"github.com/GaudiestTooth17/infection-resistant-network/evolution"
+	"github.com/GaudiestTooth17/infection-resistant-network/fileio"
 	"github.com/GaudiestTooth17/infection-resistant-network/optimized"
+	"github.com/GaudiestTooth17/infection-resistant-network/trace"
 )
 
+// seed is the master seed for all the simulations. Printing it lets a run be replayed.
+var seed = flag.Int64("seed", time.Now().UnixNano(), "master seed for the random number generators")
+
+// workers is the number of simulations that may run at once
+var workers = flag.Int("workers", 0, "number of simulations to run at once (0 uses GOMAXPROCS)")
+
+// engineName selects the engine the simulations use. It is parsed into engine.
+var engineName = flag.String("engine", "discrete", "how simulations move through time: discrete or gillespie")
+var engine dsnet.Engine
+
+// attributesName is an optional file with the susceptibility, infectiousness and group of each node
+var attributesName = flag.String("attributes", "", "file with the attributes of each node")
+
+// importation describes how infections are brought in from outside during every simulation
+var importation = flag.String("import", "",
+	"infections brought in during the run as rate:mean-per-step, pulse:n:start:interval:pulses or file:schedule-file")
+
+// vaccinate describes a vaccination campaign that runs during every simulation
+var vaccinate = flag.String("vaccinate", "",
+	"vaccination campaign as targeting:budget:efficacy[:start:doses-per-step], where targeting is random, degree, acquaintance, betweenness or ring[radius], like ring2 (radius 1 by default)")
+
+// curves makes batches of simulations also save the epidemic curve of each disease
+var curves = flag.Bool("curves", false, "save the epidemic curves of a batch as csv and png files")
+
+// percentiles are the percentile bands written to the epidemic curve csv files
+var percentiles = flag.String("percentiles", "25,75", "comma separated percentiles written to the epidemic curve csv files")
+
+// summary makes batches of simulations also print statistics about the fitness of the network
+var summary = flag.Bool("summary", false, "print statistics about the fitness of the network after a batch")
+
+// adaptive makes the number of simulations depend on how quickly the fitness settles down
+var adaptive = flag.String("adaptive", "",
+	"keep running simulations until the 95% confidence interval of the fitness is narrow enough after at least 30, as target-width:batch-size:max-sims")
+
+// traceName is an optional file that a single simulation's trace is written to
+var traceName = flag.String("trace", "", "file to write the trace of a single simulation to")
+
 func main() {
-	if len(os.Args) == 3 {
+	flag.Parse()
+	fmt.Fprintf(os.Stderr, "Seed: %d\n", *seed)
+	var err error
+	engine, err = parseEngine(*engineName)
+	checkInput(err)
+	if flag.NArg() == 2 {
 		runWithVis()
-	} else if len(os.Args) == 5 {
+	} else if flag.NArg() == 4 {
 		runBatchAndGraphR0s()
+	} else if flag.NArg() == 6 {
+		runEvolution()
 	} else {
-		fmt.Printf("Usage: %s <disease-file> <matrix-file> [num-sims] [sim-length]\n", os.Args[0])
+		fmt.Printf("Usage: %s [-seed seed] [-workers n] [-engine discrete|gillespie] [-attributes file] [-vaccinate campaign] [-import process] [-curves] [-percentiles list] [-trace file] [-summary] [-adaptive target] <disease-file> <matrix-file> [num-sims] [sim-length] [genotype-file] [num-generations]\n",
+			os.Args[0])
 		return
 	}
 }
@@ -25,56 +76,384 @@ func main() {
 // runWithVis will run one simulation and print the node states to stdout so that
 // graph-visualizer can be used to graphically inspect the simulation
 func runWithVis() {
-	diseaseName := os.Args[1]
-	matrixName := os.Args[2]
-	network := readAdjacencyList(matrixName)
-	disease := readDisease(diseaseName)
-	fitnessCalculator := optimized.NewNetworkFitnessCalculator(network, 100, 100, disease)
+	diseaseName := flag.Arg(0)
+	matrixName := flag.Arg(1)
+	network, err := fileio.ReadAdjacencyList(matrixName)
+	checkInput(err)
+	disease, err := fileio.ReadDisease(diseaseName)
+	checkInput(err)
+	checkInput(fileio.CheckDisease(disease, diseaseName, network.NumNodes()))
+	fitnessCalculator := optimized.NewNetworkFitnessCalculator(network, 100, 100, disease, *seed)
+	configure(&fitnessCalculator, network.NumNodes())
+
+	// the trace records the same simulation that is printed
+	observers := make([]dsnet.Observer, 0)
+	var traceFile *os.File
+	var traceWriter *trace.Writer
+	if *traceName != "" {
+		traceFile, err = os.Create(*traceName)
+		check(err)
+		traceWriter = trace.NewWriter(traceFile)
+		observers = append(observers, traceWriter)
+	}
 
 	timeStart := time.Now()
-	fitnessCalculator.CalcAndOutput()
+	fitnessCalculator.CalcAndOutput(observers...)
 	fmt.Fprintf(os.Stderr, "R0: %f (%v).\n",
 		fitnessCalculator.R0(), time.Now().Sub(timeStart))
+	if traceWriter != nil {
+		check(traceWriter.Err())
+		check(traceFile.Close())
+	}
 }
 
 // runBatch runs a batch of simulations and reports the average number of nodes
 // that were left susceptible at the end of each
 func runBatch() {
-	diseaseName := os.Args[1]
-	matrixName := os.Args[2]
-	disease := readDisease(diseaseName)
-	network := readAdjacencyList(matrixName)
+	diseaseName := flag.Arg(0)
+	matrixName := flag.Arg(1)
+	disease, err := fileio.ReadDisease(diseaseName)
+	checkInput(err)
+	network, err := fileio.ReadAdjacencyList(matrixName)
+	checkInput(err)
+	checkInput(fileio.CheckDisease(disease, diseaseName, network.NumNodes()))
 
-	numSims, err := strconv.Atoi(os.Args[3])
+	numSims, err := strconv.Atoi(flag.Arg(2))
 	check(err)
-	simLength, err := strconv.Atoi(os.Args[4])
+	simLength, err := strconv.Atoi(flag.Arg(3))
 	check(err)
 
-	fitnessCalculator := optimized.NewNetworkFitnessCalculator(network, numSims, simLength, disease)
+	fitnessCalculator := optimized.NewNetworkFitnessCalculator(network, numSims, simLength, disease, *seed)
+	fitnessCalculator.SetNumWorkers(*workers)
+	hasGroups := configure(&fitnessCalculator, network.NumNodes())
+	fitnessCalculator.SetProgressFunc(printProgress)
 	timeStart := time.Now()
-	fitness := fitnessCalculator.CalculateFitness()
+	results := fitnessCalculator.RunBatch(nil, false)
 	fmt.Printf("Proportion of nodes still susceptible: %f (%v).\n",
-		fitness, time.Now().Sub(timeStart))
+		results.Summary.Mean, time.Now().Sub(timeStart))
+	if hasGroups {
+		printGroupSummaries(results.Groups)
+	}
 }
 
 // runBatchAndGraphR0s runs a batch of simulations and reports the average R0 of the disease
 // it also saves a png showing a plot of R0 with respect to time step.
 func runBatchAndGraphR0s() {
-	diseaseName := os.Args[1]
-	networkName := os.Args[2]
-	disease := readDisease(diseaseName)
-	network := readAdjacencyList(networkName)
+	diseaseName := flag.Arg(0)
+	networkName := flag.Arg(1)
+	disease, err := fileio.ReadDisease(diseaseName)
+	checkInput(err)
+	network, err := fileio.ReadAdjacencyList(networkName)
+	checkInput(err)
+	checkInput(fileio.CheckDisease(disease, diseaseName, network.NumNodes()))
 
-	numSims, err := strconv.Atoi(os.Args[3])
+	numSims, err := strconv.Atoi(flag.Arg(2))
 	check(err)
-	simLength, err := strconv.Atoi(os.Args[4])
+	simLength, err := strconv.Atoi(flag.Arg(3))
 	check(err)
 
-	fitnessCalculator := optimized.NewNetworkFitnessCalculator(network, numSims, simLength, disease)
+	fitnessCalculator := optimized.NewNetworkFitnessCalculator(network, numSims, simLength, disease, *seed)
+	fitnessCalculator.SetNumWorkers(*workers)
+	hasGroups := configure(&fitnessCalculator, network.NumNodes())
+	fitnessCalculator.SetProgressFunc(printProgress)
 	timeStart := time.Now()
 	plotName := "R0s from " + noExt(diseaseName) + " on " + noExt(networkName)
-	averageR0 := fitnessCalculator.GraphAverageR0(plotName)
-	fmt.Printf("Average R0: %f (%v).\n", averageR0, time.Now().Sub(timeStart))
+	// everything is measured from the same batch of simulations
+	results := fitnessCalculator.RunBatch(nil, *curves)
+	check(results.SaveR0Plot(plotName))
+	fmt.Printf("Average R0: %f (%v).\n", results.AverageR0, time.Now().Sub(timeStart))
+	if hasGroups {
+		printGroupSummaries(results.Groups)
+	}
+	if *summary {
+		printSummary(results.Summary)
+	}
+	if *curves {
+		saveEpidemicCurves(results.Curves, "Epidemic curve of "+noExt(diseaseName)+" on "+noExt(networkName))
+	}
+}
+
+// saveEpidemicCurves saves the epidemic curves of a batch as name.csv and name.png.
+// If there is more than one disease, its index is added to the name.
+func saveEpidemicCurves(diseaseCurves []optimized.EpidemicCurve, name string) {
+	bands, err := parsePercentiles(*percentiles)
+	checkInput(err)
+	for i, curve := range diseaseCurves {
+		curveName := name
+		if len(diseaseCurves) > 1 {
+			curveName = fmt.Sprintf("%s (disease %d)", name, i)
+		}
+		file, err := os.Create(curveName + ".csv")
+		check(err)
+		check(curve.WriteCSV(file, bands...))
+		check(file.Close())
+		check(curve.SavePlot(curveName))
+	}
+}
+
+// parsePercentiles reads a comma separated list of percentiles
+func parsePercentiles(list string) ([]float64, error) {
+	if list == "" {
+		return nil, nil
+	}
+	fields := strings.Split(list, ",")
+	values := make([]float64, len(fields))
+	for i, field := range fields {
+		value, err := strconv.ParseFloat(strings.TrimSpace(field), 64)
+		if err != nil || value < 0 || value > 100 {
+			return nil, fmt.Errorf("invalid percentile %q", field)
+		}
+		values[i] = value
+	}
+	return values, nil
+}
+
+// runEvolution searches for the agent behavior that leaves the most nodes susceptible.
+// The genotypes in the genotype file are part of the first generation.
+func runEvolution() {
+	diseaseName := flag.Arg(0)
+	networkName := flag.Arg(1)
+	disease, err := fileio.ReadDisease(diseaseName)
+	checkInput(err)
+	network, err := fileio.ReadAdjacencyList(networkName)
+	checkInput(err)
+	checkInput(fileio.CheckDisease(disease, diseaseName, network.NumNodes()))
+
+	numSims, err := strconv.Atoi(flag.Arg(2))
+	check(err)
+	simLength, err := strconv.Atoi(flag.Arg(3))
+	check(err)
+	genotypes, err := fileio.ReadGenotypeConf(flag.Arg(4))
+	checkInput(err)
+	numGenerations, err := strconv.Atoi(flag.Arg(5))
+	check(err)
+
+	fitnessCalculator := optimized.NewNetworkFitnessCalculator(network, numSims, simLength, disease, *seed)
+	fitnessCalculator.SetNumWorkers(*workers)
+	configure(&fitnessCalculator, network.NumNodes())
+	maxDegree := float32(network.NumNodes() - 1)
+	manager := evolution.NewPopulationManager(evolution.Config{
+		PopulationSize: 20,
+		// minConnections, maxConnections, removeInfectedNeighborProb, addNeighborOfNeighborProb
+		GeneBounds:        []evolution.Bounds{{Min: 0, Max: maxDegree}, {Min: 0, Max: maxDegree}, {Min: 0, Max: 1}, {Min: 0, Max: 1}},
+		InitialPopulation: genotypes,
+		Selection:         evolution.NewTournamentSelection(3),
+		CrossoverRate:     .7,
+		MutationRate:      .25,
+		MutationScale:     .1,
+		NumElites:         2,
+		Seed:              *seed,
+	})
+
+	timeStart := time.Now()
+	best, history := manager.Run(fitnessCalculator, numGenerations)
+	for _, stats := range history {
+		fmt.Printf("Generation %d: best %f, mean %f, worst %f\n",
+			stats.Generation, stats.BestFitness, stats.MeanFitness, stats.WorstFitness)
+	}
+	fmt.Print("Best genotype:")
+	for i := 0; i < best.Len(); i++ {
+		fmt.Printf(" %f", best.Get(i))
+	}
+	fmt.Printf(" (%v).\n", time.Now().Sub(timeStart))
+}
+
+// parseEngine finds the engine with the given name
+func parseEngine(name string) (dsnet.Engine, error) {
+	for _, e := range []dsnet.Engine{dsnet.DiscreteEngine, dsnet.GillespieEngine} {
+		if e.String() == name {
+			return e, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown engine %q", name)
+}
+
+// configure applies the -engine, -adaptive, -import, -vaccinate and -attributes flags to the
+// calculator. It reports whether there was an attributes file.
+func configure(fitnessCalculator *optimized.NetworkFitnessCalculator, numNodes int) bool {
+	fitnessCalculator.SetEngine(engine)
+	if *adaptive != "" {
+		targetWidth, batchSize, maxTrials, err := parseAdaptive(*adaptive)
+		checkInput(err)
+		fitnessCalculator.SetAdaptiveTrials(targetWidth, batchSize, maxTrials)
+	}
+	if *importation != "" {
+		imports, err := parseImportation(*importation)
+		checkInput(err)
+		fitnessCalculator.SetImportations([]dsnet.Importation{imports})
+	}
+	if *vaccinate != "" {
+		intervention, err := parseIntervention(*vaccinate)
+		checkInput(err)
+		fitnessCalculator.SetInterventions([]dsnet.Intervention{intervention})
+	}
+	if *attributesName == "" {
+		return false
+	}
+	attributes, err := fileio.ReadNodeAttributes(*attributesName, numNodes)
+	checkInput(err)
+	fitnessCalculator.SetNodeAttributes(attributes)
+	return true
+}
+
+// parseAdaptive reads the precision adaptive trials should stop at written as
+// target-width:batch-size:max-sims
+func parseAdaptive(spec string) (targetWidth float64, batchSize, maxTrials int, err error) {
+	fields := strings.Split(spec, ":")
+	if len(fields) != 3 {
+		return 0, 0, 0, fmt.Errorf("adaptive trials %q should be target-width:batch-size:max-sims", spec)
+	}
+	targetWidth, err = strconv.ParseFloat(fields[0], 64)
+	if err != nil || targetWidth <= 0 {
+		return 0, 0, 0, fmt.Errorf("invalid confidence interval width %q", fields[0])
+	}
+	batchSize, err = strconv.Atoi(fields[1])
+	if err != nil || batchSize < 1 {
+		return 0, 0, 0, fmt.Errorf("invalid batch size %q", fields[1])
+	}
+	maxTrials, err = strconv.Atoi(fields[2])
+	if err != nil || maxTrials < 1 {
+		return 0, 0, 0, fmt.Errorf("invalid maximum number of simulations %q", fields[2])
+	}
+	return targetWidth, batchSize, maxTrials, nil
+}
+
+// parseImportation reads an importation process written as rate:mean-per-step,
+// pulse:n:start:interval:pulses or file:schedule-file
+func parseImportation(spec string) (dsnet.Importation, error) {
+	fields := strings.SplitN(spec, ":", 2)
+	if len(fields) != 2 {
+		return nil, fmt.Errorf("importation %q should start with rate:, pulse: or file:", spec)
+	}
+	switch fields[0] {
+	case "rate":
+		rate, err := strconv.ParseFloat(fields[1], 64)
+		if err != nil || rate < 0 {
+			return nil, fmt.Errorf("invalid importation rate %q", fields[1])
+		}
+		return dsnet.NewConstantImportation(rate), nil
+	case "pulse":
+		params := strings.Split(fields[1], ":")
+		if len(params) != 4 {
+			return nil, fmt.Errorf("pulses %q should be pulse:n:start:interval:pulses", spec)
+		}
+		values := make([]int, len(params))
+		for i, param := range params {
+			value, err := strconv.Atoi(param)
+			if err != nil || value < 0 {
+				return nil, fmt.Errorf("invalid pulse parameter %q", param)
+			}
+			values[i] = value
+		}
+		if values[2] == 0 {
+			return nil, fmt.Errorf("pulses %q must be at least one step apart", spec)
+		}
+		return dsnet.NewPulseImportation(values[0], uint(values[1]), uint(values[2]), values[3]), nil
+	case "file":
+		schedule, err := fileio.ReadImportationSchedule(fields[1])
+		if err != nil {
+			return nil, err
+		}
+		return dsnet.NewScheduledImportation(schedule), nil
+	}
+	return nil, fmt.Errorf("unknown importation %q", fields[0])
+}
+
+// parseIntervention reads a vaccination campaign written as
+// targeting:budget:efficacy[:start:doses-per-step]. Ring targeting has a radius of 1 unless
+// it is written after ring, like ring2.
+func parseIntervention(spec string) (dsnet.Intervention, error) {
+	fields := strings.Split(spec, ":")
+	if len(fields) != 3 && len(fields) != 5 {
+		return dsnet.Intervention{}, fmt.Errorf("vaccination %q should be targeting:budget:efficacy[:start:doses-per-step]", spec)
+	}
+	var targeting dsnet.Targeting
+	switch fields[0] {
+	case "random":
+		targeting = dsnet.NewRandomTargeting()
+	case "degree":
+		targeting = dsnet.NewDegreeTargeting()
+	case "acquaintance":
+		targeting = dsnet.NewAcquaintanceTargeting()
+	case "betweenness":
+		targeting = dsnet.NewBetweennessTargeting()
+	default:
+		// ring targeting may be followed by its radius, like ring2
+		if !strings.HasPrefix(fields[0], "ring") {
+			return dsnet.Intervention{}, fmt.Errorf("unknown targeting %q", fields[0])
+		}
+		radius := 1
+		if fields[0] != "ring" {
+			r, err := strconv.Atoi(strings.TrimPrefix(fields[0], "ring"))
+			if err != nil || r < 1 {
+				return dsnet.Intervention{}, fmt.Errorf("invalid ring radius in %q", fields[0])
+			}
+			radius = r
+		}
+		targeting = dsnet.NewRingTargeting(radius)
+	}
+	budget, err := strconv.Atoi(fields[1])
+	if err != nil || budget < 0 {
+		return dsnet.Intervention{}, fmt.Errorf("invalid vaccine budget %q", fields[1])
+	}
+	efficacy, err := strconv.ParseFloat(fields[2], 64)
+	if err != nil || efficacy < 0 || efficacy > 1 {
+		return dsnet.Intervention{}, fmt.Errorf("invalid vaccine efficacy %q", fields[2])
+	}
+	intervention := dsnet.NewIntervention(targeting, budget, efficacy)
+	if len(fields) == 5 {
+		start, err := strconv.ParseUint(fields[3], 10, 0)
+		if err != nil {
+			return dsnet.Intervention{}, fmt.Errorf("invalid vaccination start %q", fields[3])
+		}
+		dosesPerStep, err := strconv.Atoi(fields[4])
+		if err != nil || dosesPerStep < 0 {
+			return dsnet.Intervention{}, fmt.Errorf("invalid doses per step %q", fields[4])
+		}
+		intervention = intervention.Schedule(uint(start), dosesPerStep)
+	}
+	return intervention, nil
+}
+
+// printGroupSummaries prints the proportion of each group left susceptible
+func printGroupSummaries(summaries []optimized.GroupSummary) {
+	for _, summary := range summaries {
+		fmt.Printf("Group %d (%d nodes): %f still susceptible.\n",
+			summary.Group, summary.NumNodes, summary.Susceptible)
+	}
+}
+
+// printSummary prints the statistics of a batch
+func printSummary(summary optimized.FitnessSummary) {
+	fmt.Printf("Fitness over %d trials: mean %f (95%% CI %f to %f), standard deviation %f, standard error %f\n",
+		summary.NumTrials, summary.Mean, summary.ConfidenceLow, summary.ConfidenceHigh,
+		math.Sqrt(summary.Variance), summary.StdError)
+	fmt.Printf("Quantiles: min %f, 25%% %f, median %f, 75%% %f, max %f\n",
+		summary.Min, summary.LowerQuartile, summary.Median, summary.UpperQuartile, summary.Max)
+	fmt.Printf("Major outbreaks: %f of trials, mean duration %f steps\n",
+		summary.MajorOutbreakProbability, summary.MeanDuration)
+	fmt.Printf("Peak prevalence: %f on average at step %f (%v per trial).\n",
+		summary.MeanPeakPrevalence, summary.MeanTimeToPeak, summary.MeanElapsedTime)
+	if *adaptive != "" {
+		fmt.Printf("Confidence interval width: %f (target reached: %t).\n",
+			summary.ConfidenceWidth(), summary.TargetReached)
+	}
+}
+
+// printProgress overwrites a line on stderr with the number of finished trials
+func printProgress(finished, total int) {
+	fmt.Fprintf(os.Stderr, "\rFinished %d/%d trials", finished, total)
+	if finished == total {
+		fmt.Fprintln(os.Stderr)
+	}
+}
+
+// checkInput prints every problem with an input file and exits if there were any
+func checkInput(err error) {
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
 }
 
 func check(err error) {
@@ -0,0 +1,119 @@
+package networkgenerator
+
+import (
+	"math/rand"
+
+	"github.com/GaudiestTooth17/infection-resistant-network/diseasednetwork"
+)
+
+// MakeRandomRegular makes a network where every node has exactly degree neighbors,
+// chosen uniformly at random. numNodes*degree must be even.
+// It pairs up the nodes' edge stubs the way Steger and Wormald describe, starting over
+// whenever the stubs that are left can't be paired without a self loop or duplicate.
+func MakeRandomRegular(numNodes, degree int, seed int64) diseasednetwork.Network {
+	if degree < 0 || degree >= numNodes || numNodes*degree%2 != 0 {
+		panic("degree must be less than the number of nodes and numNodes*degree must be even.")
+	}
+	rand := rand.New(rand.NewSource(seed))
+	for {
+		edges, ok := tryPairingStubs(numNodes, degree, rand)
+		if ok {
+			network := diseasednetwork.NewNetwork(numNodes)
+			for _, e := range edges {
+				network.AddEdge(e.node1, e.node2, 1)
+			}
+			return network
+		}
+	}
+}
+
+// tryPairingStubs shuffles the stubs and pairs neighboring stubs. The stubs that made
+// bad pairs are shuffled again until there are none left or no good pair is possible.
+func tryPairingStubs(numNodes, degree int, rand *rand.Rand) ([]edge, bool) {
+	edgeSet := make(map[edge]bool, numNodes*degree/2)
+	edges := make([]edge, 0, numNodes*degree/2)
+	stubs := make([]int, 0, numNodes*degree)
+	for node := 0; node < numNodes; node++ {
+		for i := 0; i < degree; i++ {
+			stubs = append(stubs, node)
+		}
+	}
+
+	leftOver := make([]int, numNodes)
+	for len(stubs) > 0 {
+		rand.Shuffle(len(stubs), func(i, j int) { stubs[i], stubs[j] = stubs[j], stubs[i] })
+		for i := 0; i < len(stubs); i += 2 {
+			e := makeEdge(stubs[i], stubs[i+1])
+			if e.node1 != e.node2 && !edgeSet[e] {
+				edgeSet[e] = true
+				edges = append(edges, e)
+			} else {
+				leftOver[stubs[i]]++
+				leftOver[stubs[i+1]]++
+			}
+		}
+		if !canPair(leftOver, edgeSet) {
+			return nil, false
+		}
+		// rebuild the stubs in node order so that the shuffle is all that decides their order
+		stubs = stubs[:0]
+		for node, count := range leftOver {
+			for ; count > 0; count-- {
+				stubs = append(stubs, node)
+			}
+			leftOver[node] = 0
+		}
+	}
+	return edges, true
+}
+
+// canPair reports whether any two of the nodes with left over stubs can still be connected.
+// It is trivially true if there are no left over stubs.
+func canPair(leftOver []int, edgeSet map[edge]bool) bool {
+	nodes := make([]int, 0)
+	for node, count := range leftOver {
+		if count > 0 {
+			nodes = append(nodes, node)
+		}
+	}
+	if len(nodes) == 0 {
+		return true
+	}
+	for i, node1 := range nodes {
+		for _, node2 := range nodes[i+1:] {
+			if !edgeSet[makeEdge(node1, node2)] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// MakeConfigurationModel makes a random network where node i has degrees[i] edge stubs
+// and the stubs are paired uniformly at random. The sum of degrees must be even.
+// Self loops and duplicate edges are thrown away, so some nodes may end up with
+// slightly smaller degrees than they asked for. This is the "erased" configuration model.
+func MakeConfigurationModel(degrees []int, seed int64) diseasednetwork.Network {
+	stubs := make([]int, 0)
+	for node, degree := range degrees {
+		if degree < 0 {
+			panic("Degrees must be nonnegative.")
+		}
+		for i := 0; i < degree; i++ {
+			stubs = append(stubs, node)
+		}
+	}
+	if len(stubs)%2 != 0 {
+		panic("The sum of the degrees must be even.")
+	}
+
+	rand := rand.New(rand.NewSource(seed))
+	rand.Shuffle(len(stubs), func(i, j int) { stubs[i], stubs[j] = stubs[j], stubs[i] })
+	network := diseasednetwork.NewNetwork(len(degrees))
+	for i := 0; i < len(stubs); i += 2 {
+		if stubs[i] != stubs[i+1] {
+			network.AddEdge(stubs[i], stubs[i+1], 1)
+		}
+	}
+	return network
+}
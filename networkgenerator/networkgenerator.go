@@ -0,0 +1,254 @@
+// Package networkgenerator makes diseasednetwork.Networks with well known topologies.
+// Every random generator takes a seed, so the same arguments always produce the same network.
+// All edges have a weight of 1.
+package networkgenerator
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/GaudiestTooth17/infection-resistant-network/diseasednetwork"
+)
+
+// MakeCompleteNetwork returns a complete network with the given number of nodes
+func MakeCompleteNetwork(numNodes int) diseasednetwork.Network {
+	network := diseasednetwork.NewNetwork(numNodes)
+	for i := 0; i < numNodes; i++ {
+		for j := i + 1; j < numNodes; j++ {
+			network.AddEdge(i, j, 1)
+		}
+	}
+	return network
+}
+
+// MakeCircularNetwork makes a network resembling a ring where each node has degree 2
+func MakeCircularNetwork(numNodes int) diseasednetwork.Network {
+	network := diseasednetwork.NewNetwork(numNodes)
+	for i := 0; i < numNodes; i++ {
+		network.AddEdge(i, (i+1)%numNodes, 1)
+	}
+	return network
+}
+
+// MakeLattice makes a rows by cols grid where each node is connected to the nodes above,
+// below, left and right of it. Node r*cols+c is in row r and column c.
+// If periodic is true the edges of the grid wrap around so that every node has degree 4.
+// That needs at least 3 rows and 3 columns, because with fewer the edges that wrap around
+// would be the ones already in the grid.
+func MakeLattice(rows, cols int, periodic bool) diseasednetwork.Network {
+	if periodic && (rows < 3 || cols < 3) {
+		panic("A periodic lattice must have at least 3 rows and 3 columns!")
+	}
+	network := diseasednetwork.NewNetwork(rows * cols)
+	for r := 0; r < rows; r++ {
+		for c := 0; c < cols; c++ {
+			node := r*cols + c
+			if c+1 < cols {
+				network.AddEdge(node, node+1, 1)
+			} else if periodic {
+				network.AddEdge(node, r*cols, 1)
+			}
+			if r+1 < rows {
+				network.AddEdge(node, node+cols, 1)
+			} else if periodic {
+				network.AddEdge(node, c, 1)
+			}
+		}
+	}
+	return network
+}
+
+// MakeErdosRenyi makes a G(n, p) random network where each pair of nodes is connected
+// with probability edgeProb. It runs in time proportional to the number of edges.
+func MakeErdosRenyi(numNodes int, edgeProb float64, seed int64) diseasednetwork.Network {
+	checkProbability(edgeProb)
+	rand := rand.New(rand.NewSource(seed))
+	network := diseasednetwork.NewNetwork(numNodes)
+	addRandomEdges(&network, 0, numNodes, edgeProb, rand)
+	return network
+}
+
+// MakeStochasticBlockModel makes a network whose nodes are divided into blocks.
+// Block i holds blockSizes[i] consecutive nodes, starting with block 0 at node 0.
+// A node in block i is connected to a node in block j with probability edgeProbs[i][j],
+// so edgeProbs must be a symmetric matrix with one row for each block.
+func MakeStochasticBlockModel(blockSizes []int, edgeProbs [][]float64, seed int64) diseasednetwork.Network {
+	if len(edgeProbs) != len(blockSizes) {
+		panic("There must be a row of edge probabilities for each block!")
+	}
+	for i := range edgeProbs {
+		if len(edgeProbs[i]) != len(blockSizes) {
+			panic("There must be an edge probability for each pair of blocks!")
+		}
+		for j := range edgeProbs[i] {
+			checkProbability(edgeProbs[i][j])
+			if edgeProbs[i][j] != edgeProbs[j][i] {
+				panic("Edge probabilities must be symmetric!")
+			}
+		}
+	}
+
+	rand := rand.New(rand.NewSource(seed))
+	offsets := make([]int, len(blockSizes)+1)
+	for i, size := range blockSizes {
+		offsets[i+1] = offsets[i] + size
+	}
+	network := diseasednetwork.NewNetwork(offsets[len(blockSizes)])
+	for i := range blockSizes {
+		addRandomEdges(&network, offsets[i], blockSizes[i], edgeProbs[i][i], rand)
+		for j := i + 1; j < len(blockSizes); j++ {
+			addRandomBipartiteEdges(&network, offsets[i], blockSizes[i], offsets[j], blockSizes[j],
+				edgeProbs[i][j], rand)
+		}
+	}
+	return network
+}
+
+// MakeBarabasiAlbert makes a scale free network by preferential attachment.
+// It starts with a complete network of m+1 nodes, and then every new node is connected
+// to m distinct existing nodes chosen with probability proportional to their degree.
+func MakeBarabasiAlbert(numNodes, m int, seed int64) diseasednetwork.Network {
+	if m < 1 || m >= numNodes {
+		panic("m must be at least 1 and less than the number of nodes.")
+	}
+	rand := rand.New(rand.NewSource(seed))
+	network := diseasednetwork.NewNetwork(numNodes)
+	// every node appears in endpoints once for each of its edges,
+	// so choosing uniformly from endpoints is preferential attachment
+	endpoints := make([]int, 0, 2*m*numNodes)
+	for i := 0; i <= m; i++ {
+		for j := i + 1; j <= m; j++ {
+			network.AddEdge(i, j, 1)
+			endpoints = append(endpoints, i, j)
+		}
+	}
+
+	targets := make([]int, 0, m)
+	for node := m + 1; node < numNodes; node++ {
+		targets = targets[:0]
+		for len(targets) < m {
+			target := endpoints[rand.Intn(len(endpoints))]
+			if !network.HasEdge(node, target) {
+				network.AddEdge(node, target, 1)
+				targets = append(targets, target)
+			}
+		}
+		for _, target := range targets {
+			endpoints = append(endpoints, node, target)
+		}
+	}
+	return network
+}
+
+// MakeWattsStrogatz makes a small world network. It starts with a ring where every node is
+// connected to its k nearest neighbors (k/2 on each side), and then the far end of each edge
+// is moved to a uniformly random node with probability rewireProb.
+// Edges are never rewired into self loops or duplicates.
+func MakeWattsStrogatz(numNodes, k int, rewireProb float64, seed int64) diseasednetwork.Network {
+	if k%2 != 0 || k < 2 || k >= numNodes {
+		panic("k must be even, at least 2 and less than the number of nodes.")
+	}
+	checkProbability(rewireProb)
+	rand := rand.New(rand.NewSource(seed))
+	edges := make(map[edge]bool)
+	degrees := make([]int, numNodes)
+	for j := 1; j <= k/2; j++ {
+		for node := 0; node < numNodes; node++ {
+			edges[makeEdge(node, (node+j)%numNodes)] = true
+			degrees[node]++
+			degrees[(node+j)%numNodes]++
+		}
+	}
+
+	for j := 1; j <= k/2; j++ {
+		for node := 0; node < numNodes; node++ {
+			oldEdge := makeEdge(node, (node+j)%numNodes)
+			if rand.Float64() >= rewireProb || !edges[oldEdge] || degrees[node] >= numNodes-1 {
+				continue
+			}
+			newNeighbor := rand.Intn(numNodes)
+			for newNeighbor == node || edges[makeEdge(node, newNeighbor)] {
+				newNeighbor = rand.Intn(numNodes)
+			}
+			delete(edges, oldEdge)
+			degrees[(node+j)%numNodes]--
+			edges[makeEdge(node, newNeighbor)] = true
+			degrees[newNeighbor]++
+		}
+	}
+
+	network := diseasednetwork.NewNetwork(numNodes)
+	for e := range edges {
+		network.AddEdge(e.node1, e.node2, 1)
+	}
+	return network
+}
+
+// edge is an undirected edge with the smaller node first so that it can be used as a map key
+type edge struct {
+	node1, node2 int
+}
+
+func makeEdge(node1, node2 int) edge {
+	if node1 > node2 {
+		node1, node2 = node2, node1
+	}
+	return edge{node1: node1, node2: node2}
+}
+
+// checkProbability panics if p is not a valid probability
+func checkProbability(p float64) {
+	if p < 0 || p > 1 || math.IsNaN(p) {
+		panic("Probabilities must be at least 0 and at most 1.")
+	}
+}
+
+// maxSkip is larger than the number of pairs in any network that fits in memory. It stays far
+// enough from the largest int that adding to it can't overflow.
+const maxSkip = int(^uint(0) >> 2)
+
+// skip returns how many pairs to pass over before the next edge when each pair is connected
+// with probability p. The gaps between edges follow a geometric distribution. Log1p keeps tiny
+// probabilities from rounding to 0, and gaps too large to be an int are cut down to maxSkip.
+func skip(p float64, rand *rand.Rand) int {
+	if p >= 1 {
+		return 0
+	}
+	gap := math.Log(1-rand.Float64()) / math.Log1p(-p)
+	if gap >= float64(maxSkip) {
+		return maxSkip
+	}
+	return int(gap)
+}
+
+// addRandomEdges connects each pair of nodes in [offset, offset+size) with probability p.
+// It uses the method of Batagelj and Brandes to visit only the pairs that get an edge.
+func addRandomEdges(network *diseasednetwork.Network, offset, size int, p float64, rand *rand.Rand) {
+	if p <= 0 {
+		return
+	}
+	// (v, w) walks the lower triangle of the adjacency matrix
+	v, w := 1, -1
+	for v < size {
+		w += 1 + skip(p, rand)
+		for w >= v && v < size {
+			w -= v
+			v++
+		}
+		if v < size {
+			network.AddEdge(offset+v, offset+w, 1)
+		}
+	}
+}
+
+// addRandomBipartiteEdges connects each node in [offset1, offset1+size1) to each node in
+// [offset2, offset2+size2) with probability p
+func addRandomBipartiteEdges(network *diseasednetwork.Network, offset1, size1, offset2, size2 int,
+	p float64, rand *rand.Rand) {
+	if p <= 0 {
+		return
+	}
+	for pair := skip(p, rand); pair < size1*size2; pair += 1 + skip(p, rand) {
+		network.AddEdge(offset1+pair/size2, offset2+pair%size2, 1)
+	}
+}
@@ -0,0 +1,170 @@
+package networkgenerator
+
+import (
+	"math"
+	"testing"
+
+	"github.com/GaudiestTooth17/infection-resistant-network/diseasednetwork"
+)
+
+func numEdges(network diseasednetwork.Network) int {
+	total := 0
+	for node := 0; node < network.NumNodes(); node++ {
+		total += network.Degree(node)
+	}
+	return total / 2
+}
+
+func sameNetwork(network1, network2 diseasednetwork.Network) bool {
+	if network1.NumNodes() != network2.NumNodes() {
+		return false
+	}
+	for node := 0; node < network1.NumNodes(); node++ {
+		neighbors1 := network1.Neighbors(node)
+		neighbors2 := network2.Neighbors(node)
+		if len(neighbors1) != len(neighbors2) {
+			return false
+		}
+		for i := range neighbors1 {
+			if neighbors1[i] != neighbors2[i] {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func TestFixedNetworks(t *testing.T) {
+	if edges := numEdges(MakeCompleteNetwork(20)); edges != 190 {
+		t.Errorf("Expected 190 edges in a complete network of 20 nodes, found %d", edges)
+	}
+	if edges := numEdges(MakeCircularNetwork(20)); edges != 20 {
+		t.Errorf("Expected 20 edges in a circular network of 20 nodes, found %d", edges)
+	}
+
+	lattice := MakeLattice(5, 6, false)
+	if edges := numEdges(lattice); edges != 5*5+4*6 {
+		t.Errorf("Expected %d edges in a 5x6 lattice, found %d", 5*5+4*6, edges)
+	}
+	if lattice.Degree(0) != 2 || lattice.Degree(7) != 4 || !lattice.HasEdge(7, 13) {
+		t.Errorf("Expected corners to have degree 2 and node 7 to be above node 13")
+	}
+	torus := MakeLattice(5, 6, true)
+	for node := 0; node < torus.NumNodes(); node++ {
+		if torus.Degree(node) != 4 {
+			t.Errorf("Expected node %d of a periodic lattice to have degree 4, has %d", node, torus.Degree(node))
+		}
+	}
+}
+
+func TestSmallPeriodicLattice(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected a periodic lattice with 2 rows to panic")
+		}
+	}()
+	MakeLattice(2, 6, true)
+}
+
+func TestErdosRenyi(t *testing.T) {
+	numNodes := 2000
+	p := .005
+	network := MakeErdosRenyi(numNodes, p, 1)
+	expected := p * float64(numNodes*(numNodes-1)/2)
+	if math.Abs(float64(numEdges(network))-expected) > 4*math.Sqrt(expected) {
+		t.Errorf("Expected about %f edges, found %d", expected, numEdges(network))
+	}
+	if numEdges(MakeErdosRenyi(30, 1, 1)) != 435 || numEdges(MakeErdosRenyi(30, 0, 1)) != 0 {
+		t.Errorf("Expected p = 1 to be complete and p = 0 to be empty")
+	}
+	// 1 - p rounds to 1 for a p this small
+	if numEdges(MakeErdosRenyi(300, 1e-18, 1)) != 0 {
+		t.Errorf("Expected a tiny p to give no edges")
+	}
+	if numEdges(MakeStochasticBlockModel([]int{300, 300}, [][]float64{{1e-18, 1e-18}, {1e-18, 1e-18}}, 1)) != 0 {
+		t.Errorf("Expected tiny block probabilities to give no edges")
+	}
+	if !sameNetwork(network, MakeErdosRenyi(numNodes, p, 1)) || sameNetwork(network, MakeErdosRenyi(numNodes, p, 2)) {
+		t.Errorf("Expected the network to depend only on the seed")
+	}
+}
+
+func TestStochasticBlockModel(t *testing.T) {
+	network := MakeStochasticBlockModel([]int{10, 15}, [][]float64{{1, 0}, {0, 1}}, 1)
+	if numEdges(network) != 45+105 || network.HasEdge(9, 10) {
+		t.Errorf("Expected two disconnected complete blocks")
+	}
+	network = MakeStochasticBlockModel([]int{10, 15}, [][]float64{{0, 1}, {1, 0}}, 1)
+	if numEdges(network) != 150 || network.HasEdge(0, 1) || !network.HasEdge(0, 24) {
+		t.Errorf("Expected a complete bipartite network")
+	}
+}
+
+func TestBarabasiAlbert(t *testing.T) {
+	numNodes, m := 500, 3
+	network := MakeBarabasiAlbert(numNodes, m, 1)
+	expected := m*(m+1)/2 + (numNodes-m-1)*m
+	if numEdges(network) != expected {
+		t.Errorf("Expected %d edges, found %d", expected, numEdges(network))
+	}
+	maxDegree := 0
+	for node := 0; node < numNodes; node++ {
+		if network.Degree(node) < m {
+			t.Errorf("Expected node %d to have at least %d neighbors, has %d", node, m, network.Degree(node))
+		}
+		if network.Degree(node) > maxDegree {
+			maxDegree = network.Degree(node)
+		}
+	}
+	if maxDegree < 5*m {
+		t.Errorf("Expected preferential attachment to make a hub, largest degree is %d", maxDegree)
+	}
+}
+
+func TestWattsStrogatz(t *testing.T) {
+	lattice := MakeWattsStrogatz(100, 6, 0, 1)
+	for node := 0; node < 100; node++ {
+		if lattice.Degree(node) != 6 || !lattice.HasEdge(node, (node+3)%100) {
+			t.Errorf("Expected node %d to be connected to its 6 nearest neighbors", node)
+		}
+	}
+	network := MakeWattsStrogatz(100, 6, .5, 1)
+	if numEdges(network) != 300 {
+		t.Errorf("Expected rewiring to keep all 300 edges, found %d", numEdges(network))
+	}
+	if sameNetwork(lattice, network) {
+		t.Errorf("Expected some edges to be rewired")
+	}
+}
+
+func TestRandomRegular(t *testing.T) {
+	for _, degree := range []int{1, 3, 10} {
+		network := MakeRandomRegular(100, degree, 1)
+		for node := 0; node < network.NumNodes(); node++ {
+			if network.Degree(node) != degree {
+				t.Errorf("Expected node %d to have degree %d, has %d", node, degree, network.Degree(node))
+			}
+		}
+	}
+}
+
+func TestConfigurationModel(t *testing.T) {
+	degrees := make([]int, 200)
+	total := 0
+	for node := range degrees {
+		degrees[node] = 1 + node%7
+		total += degrees[node]
+	}
+	network := MakeConfigurationModel(degrees, 1)
+	actualTotal := 0
+	for node, degree := range degrees {
+		if network.Degree(node) > degree {
+			t.Errorf("Expected node %d to have at most %d neighbors, has %d", node, degree, network.Degree(node))
+		}
+		actualTotal += network.Degree(node)
+	}
+	// only a few self loops and duplicates should have been erased
+	if actualTotal < total*9/10 {
+		t.Errorf("Expected about %d edge ends, found %d", total, actualTotal)
+	}
+}
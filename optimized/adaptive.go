@@ -0,0 +1,34 @@
+package optimized
+
+// minAdaptiveTrials is the fewest trials adaptive trials stop after. Smaller batches often
+// have every trial end the same way, like when outbreaks die out early, and look far more
+// precise than they are.
+const minAdaptiveTrials = 30
+
+// SetAdaptiveTrials makes every batch keep running trials until the 95% confidence interval
+// for the mean fitness is no wider than targetWidth.
+// The first numTrials trials are run together and then more are run batchSize at a time, so
+// clearly good or clearly bad behaviors can be measured with few trials.
+// No more than maxTrials trials are run, but never fewer than numTrials. The target can't be
+// reached with fewer than 30 trials however precise they look.
+// The trials get the same seeds as they would if numTrials were larger, so the results are
+// still reproducible. A targetWidth of 0 turns adaptive trials off.
+func (n *NetworkFitnessCalculator) SetAdaptiveTrials(targetWidth float64, batchSize, maxTrials int) {
+	if targetWidth > 0 && batchSize < 1 {
+		panic("Adaptive trials must be run in batches of at least one trial!")
+	}
+	n.targetWidth = targetWidth
+	n.batchSize = batchSize
+	n.maxTrials = maxTrials
+}
+
+// adaptive reports whether the number of trials is adaptive
+func (n NetworkFitnessCalculator) adaptive() bool {
+	return n.targetWidth > 0
+}
+
+// targetReached reports whether summary is as precise as SetAdaptiveTrials asked for.
+// It takes at least minAdaptiveTrials trials to trust the estimate of the precision.
+func (n NetworkFitnessCalculator) targetReached(summary FitnessSummary) bool {
+	return n.adaptive() && summary.NumTrials >= minAdaptiveTrials && summary.ConfidenceWidth() <= n.targetWidth
+}
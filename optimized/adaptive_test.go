@@ -0,0 +1,92 @@
+package optimized
+
+import (
+	"testing"
+
+	dsnet "github.com/GaudiestTooth17/infection-resistant-network/diseasednetwork"
+	"github.com/GaudiestTooth17/infection-resistant-network/dynamicnet"
+	"github.com/GaudiestTooth17/infection-resistant-network/networkgenerator"
+)
+
+// TestAdaptiveTrials makes sure batches are added until the confidence interval is narrow enough
+func TestAdaptiveTrials(t *testing.T) {
+	behavior := dynamicnet.NewSimpleBehavior(1, 6, .5, .1)
+	fixed := makeCalculator(5).Summarize(behavior)
+	if fixed.TargetReached {
+		t.Error("Expected the target to never be reached without adaptive trials")
+	}
+
+	calculator := makeCalculator(5)
+	calculator.SetAdaptiveTrials(fixed.ConfidenceWidth()/2, 10, 1000)
+	summary := calculator.Summarize(behavior)
+	if !summary.TargetReached || summary.ConfidenceWidth() > fixed.ConfidenceWidth()/2 {
+		t.Errorf("Expected the confidence interval to be narrower than %f, found %f",
+			fixed.ConfidenceWidth()/2, summary.ConfidenceWidth())
+	}
+	if summary.NumTrials <= 20 || (summary.NumTrials-20)%10 != 0 {
+		t.Errorf("Expected the first 20 trials to be followed by batches of 10, found %d trials", summary.NumTrials)
+	}
+
+	// the adaptive trials should be the same ones a larger fixed batch would run
+	same := NewNetworkFitnessCalculator(calculator.network, summary.NumTrials, calculator.simLength,
+		calculator.disease, 5)
+	if same.Summarize(behavior).Mean != summary.Mean {
+		t.Error("Expected the adaptive trials to match a fixed batch of the same size")
+	}
+	// and one less batch shouldn't have been enough
+	fewer := NewNetworkFitnessCalculator(calculator.network, summary.NumTrials-10, calculator.simLength,
+		calculator.disease, 5)
+	if width := fewer.Summarize(behavior).ConfidenceWidth(); width <= fixed.ConfidenceWidth()/2 {
+		t.Errorf("Expected %d trials to not be enough, but the width was %f", summary.NumTrials-10, width)
+	}
+	if fitness := calculator.BehaviorFitness(behavior); fitness != float32(summary.Mean) {
+		t.Errorf("Expected BehaviorFitness to use the adaptive trials, found %f instead of %f", fitness, summary.Mean)
+	}
+}
+
+func TestAdaptiveTrialCap(t *testing.T) {
+	behavior := dynamicnet.NewSimpleBehavior(1, 6, .5, .1)
+	calculator := makeCalculator(5)
+	calculator.SetAdaptiveTrials(1e-9, 15, 42)
+	summary := calculator.Summarize(behavior)
+	if summary.NumTrials != 42 || summary.TargetReached {
+		t.Errorf("Expected to stop at 42 trials without reaching the target, found %d and %t",
+			summary.NumTrials, summary.TargetReached)
+	}
+
+	calculator.SetAdaptiveTrials(1, 15, 42)
+	if summary := calculator.Summarize(behavior); summary.NumTrials != 35 || !summary.TargetReached {
+		t.Errorf("Expected one batch past the first 20 trials to be precise enough, found %d and %t",
+			summary.NumTrials, summary.TargetReached)
+	}
+}
+
+// TestAdaptiveTrialMinimum makes sure identical trials don't stop adaptive trials right away
+func TestAdaptiveTrialMinimum(t *testing.T) {
+	network := networkgenerator.MakeCompleteNetwork(50)
+	calculator := NewNetworkFitnessCalculator(network, 10, 20, dsnet.NewBasicDisease(1, 2, 0, dsnet.NewInfectN(2)), 1)
+	calculator.SetAdaptiveTrials(.01, 5, 100)
+	summary := calculator.Summarize(nil)
+	if summary.NumTrials != minAdaptiveTrials || !summary.TargetReached || summary.ConfidenceWidth() != 0 {
+		t.Errorf("Expected trials that can't differ to stop after %d, found %d and %t",
+			minAdaptiveTrials, summary.NumTrials, summary.TargetReached)
+	}
+}
+
+// TestAdaptiveProgress makes sure progress keeps counting through the added batches
+func TestAdaptiveProgress(t *testing.T) {
+	calculator := makeCalculator(5)
+	calculator.SetNumWorkers(3)
+	calculator.SetAdaptiveTrials(1e-9, 15, 42)
+	lastFinished, lastTotal := 0, 0
+	calculator.SetProgressFunc(func(finished, total int) {
+		if finished != lastFinished+1 || total < lastTotal || finished > total {
+			t.Errorf("Expected progress %d/%d or more, found %d/%d", lastFinished+1, lastTotal, finished, total)
+		}
+		lastFinished, lastTotal = finished, total
+	})
+	calculator.Summarize(nil)
+	if lastFinished != 42 || lastTotal != 42 {
+		t.Errorf("Expected progress to reach 42/42, reached %d/%d", lastFinished, lastTotal)
+	}
+}
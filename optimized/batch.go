@@ -0,0 +1,272 @@
+package optimized
+
+import (
+	"context"
+	"sort"
+
+	dsnet "github.com/GaudiestTooth17/infection-resistant-network/diseasednetwork"
+	"github.com/GaudiestTooth17/infection-resistant-network/dynamicnet"
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/plotter"
+	"gonum.org/v1/plot/plotutil"
+	"gonum.org/v1/plot/vg"
+)
+
+// BatchResults holds everything that is measured in a batch of trials. It all comes from the
+// same trials, so the numbers describe the same simulations.
+type BatchResults struct {
+	// AverageR0 is the pooled estimate of R0 from the initial infections (see InfectionTree.R0)
+	AverageR0 float64
+	// Groups breaks the fitness down by the groups in the node attributes (see GroupFitness)
+	Groups []GroupSummary
+	// Summary describes the fitness and the outbreaks (see Summarize)
+	Summary FitnessSummary
+	// Curves holds the epidemic curve of every disease if they were asked for (see EpidemicCurves)
+	Curves []EpidemicCurve
+	// cases holds the infection tree of the bad disease from every trial
+	cases [][]dsnet.InfectionCase
+	// numSteps is the number of steps in each trial
+	numSteps int
+}
+
+// trialRecord is everything a batch needs to know about one trial
+type trialRecord struct {
+	outcome trialOutcome
+	cases   []dsnet.InfectionCase
+	// groupCounts holds the number of nodes in each group left susceptible or protected by a
+	// vaccine, indexed like the summaries from groups
+	groupCounts []int
+	// curve is nil unless the batch collects epidemic curves
+	curve *dsnet.EpidemicCurveRecorder
+}
+
+// RunBatch runs a batch of simulations with the agents following behavior and measures
+// everything in BatchResults from them. It runs numTrials trials, or more if SetAdaptiveTrials
+// was used. Epidemic curves take memory for every step of every trial, so they are only
+// collected if withCurves is true. Pass a nil behavior to use the static network.
+func (n NetworkFitnessCalculator) RunBatch(behavior dynamicnet.AgentBehavior, withCurves bool) BatchResults {
+	results, _ := n.RunBatchContext(context.Background(), behavior, withCurves)
+	return results
+}
+
+// RunBatchContext is RunBatch, but it stops early and returns
+// the context's error if ctx is cancelled
+func (n NetworkFitnessCalculator) RunBatchContext(ctx context.Context, behavior dynamicnet.AgentBehavior,
+	withCurves bool) (BatchResults, error) {
+	records, err := n.adaptiveRecords(ctx, behavior, withCurves)
+	if err != nil {
+		return BatchResults{}, err
+	}
+
+	results := BatchResults{cases: make([][]dsnet.InfectionCase, len(records)), numSteps: n.simLength}
+	outcomes := make([]trialOutcome, len(records))
+	for trial, record := range records {
+		outcomes[trial] = record.outcome
+		results.cases[trial] = record.cases
+	}
+	results.AverageR0 = pooledR0(results.cases)
+	results.Summary = summarize(outcomes)
+	results.Summary.TargetReached = n.targetReached(results.Summary)
+	results.Summary.seed = n.seed
+	results.Summary.seeded = true
+
+	results.Groups, _ = n.groups()
+	for _, record := range records {
+		for i, count := range record.groupCounts {
+			results.Groups[i].Susceptible += float32(count) / float32(results.Groups[i].NumNodes*len(records))
+		}
+	}
+	sort.Slice(results.Groups, func(i, j int) bool { return results.Groups[i].Group < results.Groups[j].Group })
+
+	if withCurves && len(records) > 0 {
+		first := records[0].curve
+		results.Curves = make([]EpidemicCurve, first.NumDiseases())
+		for disease := range results.Curves {
+			trials := make([][][]int, len(records))
+			for trial, record := range records {
+				trials[trial] = record.curve.Counts(disease)
+			}
+			results.Curves[disease] = newEpidemicCurve(first.CompartmentNames(disease), trials)
+		}
+	}
+	return results, nil
+}
+
+// groups gives each group in the node attributes an index so that the trials can count with
+// slices. The summaries are in order of each group's first node and only have NumNodes filled in.
+func (n NetworkFitnessCalculator) groups() ([]GroupSummary, map[int]int) {
+	groupIndex := make(map[int]int)
+	summaries := make([]GroupSummary, 0)
+	for node := 0; node < n.network.NumNodes(); node++ {
+		group := n.network.Group(node)
+		if _, ok := groupIndex[group]; !ok {
+			groupIndex[group] = len(summaries)
+			summaries = append(summaries, GroupSummary{Group: group})
+		}
+		summaries[groupIndex[group]].NumNodes++
+	}
+	return summaries, groupIndex
+}
+
+// adaptiveRecords runs numTrials trials and then, if the number of trials is adaptive,
+// more batches until the target precision or the maximum number of trials is reached
+func (n NetworkFitnessCalculator) adaptiveRecords(ctx context.Context, behavior dynamicnet.AgentBehavior,
+	withCurves bool) ([]trialRecord, error) {
+	records, err := n.trialRecords(ctx, behavior, 0, n.numTrials, withCurves)
+	if err != nil || !n.adaptive() {
+		return records, err
+	}
+	outcomes := make([]trialOutcome, 0, len(records))
+	for _, record := range records {
+		outcomes = append(outcomes, record.outcome)
+	}
+	for len(records) < n.maxTrials && !n.targetReached(summarize(outcomes)) {
+		count := n.batchSize
+		if len(records)+count > n.maxTrials {
+			count = n.maxTrials - len(records)
+		}
+		batch, err := n.trialRecords(ctx, behavior, len(records), count, withCurves)
+		if err != nil {
+			return nil, err
+		}
+		for _, record := range batch {
+			outcomes = append(outcomes, record.outcome)
+		}
+		records = append(records, batch...)
+	}
+	return records, nil
+}
+
+// trialRecords runs count trials starting with trial number first
+func (n NetworkFitnessCalculator) trialRecords(ctx context.Context, behavior dynamicnet.AgentBehavior,
+	first, count int, withCurves bool) ([]trialRecord, error) {
+	records := make([]trialRecord, count)
+	summaries, groupIndex := n.groups()
+	numNodes := float64(n.network.NumNodes())
+	err := n.runTrials(ctx, first, count, func(ctx context.Context, trial int) error {
+		outbreak := &outbreakObserver{}
+		incidence := dsnet.NewIncidenceCounter(0)
+		observers := []dsnet.Observer{outbreak, incidence}
+		var curve *dsnet.EpidemicCurveRecorder
+		if withCurves {
+			curve = dsnet.NewEpidemicCurveRecorder()
+			observers = append(observers, curve)
+		}
+		network := n.newTrialNetwork(behavior, dsnet.DeriveSeed(n.seed, trial), observers...)
+		fData, err := calcTrial(ctx, trial, &network, n.simLength)
+		if err != nil {
+			return err
+		}
+
+		numInfected := 0
+		for _, count := range incidence.Incidence() {
+			numInfected += count
+		}
+		duration := outbreak.duration
+		if duration == 0 && network.NumInfected(0) > 0 {
+			duration = uint(n.simLength)
+		}
+		groupCounts := make([]int, len(summaries))
+		for node, state := range network.GetNodeStates(0) {
+			if state == dsnet.StateS || network.IsImmunized(node) {
+				groupCounts[groupIndex[network.Group(node)]]++
+			}
+		}
+		records[trial-first] = trialRecord{
+			outcome: trialOutcome{
+				fitness:       float64(fData.fitness),
+				elapsedTime:   fData.elapsedTime,
+				majorOutbreak: float64(numInfected)/numNodes > n.outbreakThreshold,
+				duration:      duration,
+				peak:          float64(outbreak.peak) / numNodes,
+				timeToPeak:    outbreak.timeToPeak,
+			},
+			cases:       network.InfectionTree(0).Cases(),
+			groupCounts: groupCounts,
+			curve:       curve,
+		}
+		return nil
+	})
+	return records, err
+}
+
+// pooledR0 pools the initial infections from every trial and returns the average number of
+// cases they caused. Only resolved cases are counted because the others may cause more.
+// Imported infections are left out just like in InfectionTree.R0.
+func pooledR0(allCases [][]dsnet.InfectionCase) float64 {
+	numIndexCases := 0
+	numIndexOffspring := 0
+	for _, cases := range allCases {
+		for _, c := range cases {
+			if c.IsInitial() && c.Resolved {
+				numIndexCases++
+				numIndexOffspring += c.Offspring
+			}
+		}
+	}
+	if numIndexCases == 0 {
+		return 0
+	}
+	return float64(numIndexOffspring) / float64(numIndexCases)
+}
+
+// SaveR0Plot graphs the effective reproduction number of the cases that started in each step,
+// pooled across all the trials, and saves it as plotName.png. The interquartile range of the
+// effective reproduction number in the trials that had cases start in a step is shaded behind it.
+func (r BatchResults) SaveR0Plot(plotName string) error {
+	// pool the cases from every trial by the step they started in, and keep each trial's
+	// own effective R for the steps it had cases in
+	numCases := make([]int, r.numSteps+1)
+	numOffspring := make([]int, r.numSteps+1)
+	trialRs := make([][]float64, r.numSteps+1)
+	for _, cases := range r.cases {
+		trialCases := make([]int, r.numSteps+1)
+		trialOffspring := make([]int, r.numSteps+1)
+		for _, c := range cases {
+			trialCases[c.Step]++
+			trialOffspring[c.Step] += c.Offspring
+		}
+		for step, count := range trialCases {
+			if count > 0 {
+				numCases[step] += count
+				numOffspring[step] += trialOffspring[step]
+				trialRs[step] = append(trialRs[step], float64(trialOffspring[step])/float64(count))
+			}
+		}
+	}
+
+	// save the plot
+	p, err := plot.New()
+	if err != nil {
+		return err
+	}
+	p.Title.Text = plotName
+	p.X.Label.Text = "Time Step of Infection"
+	p.Y.Label.Text = "Effective R"
+	points := make(plotter.XYs, r.numSteps+1)
+	// the band goes forward along the upper quartile and back along the lower one
+	band := make(plotter.XYs, 2*(r.numSteps+1))
+	for step := range points {
+		points[step].X = float64(step)
+		if numCases[step] > 0 {
+			points[step].Y = float64(numOffspring[step]) / float64(numCases[step])
+		}
+		sort.Float64s(trialRs[step])
+		band[step] = plotter.XY{X: float64(step), Y: percentile(trialRs[step], 75)}
+		band[len(band)-1-step] = plotter.XY{X: float64(step), Y: percentile(trialRs[step], 25)}
+	}
+
+	// add the band and the points to the plot and save the plot
+	polygon, err := plotter.NewPolygon(band)
+	if err != nil {
+		return err
+	}
+	polygon.Color = translucent(plotutil.Color(0))
+	polygon.LineStyle.Width = 0
+	p.Add(polygon)
+	p.Legend.Add("interquartile range of trials", polygon)
+	if err := plotutil.AddLines(p, plotName, points); err != nil {
+		return err
+	}
+	return p.Save(8*vg.Inch, 8*vg.Inch, plotName+".png")
+}
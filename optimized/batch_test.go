@@ -0,0 +1,52 @@
+package optimized
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/GaudiestTooth17/infection-resistant-network/dynamicnet"
+)
+
+// TestRunBatch makes sure one batch gives the same results as running each kind on its own
+func TestRunBatch(t *testing.T) {
+	behavior := dynamicnet.NewSimpleBehavior(1, 6, .5, .1)
+	calculator := makeCalculator(13)
+	numFinished := 0
+	calculator.SetProgressFunc(func(finished, total int) {
+		numFinished++
+	})
+	results := calculator.RunBatch(behavior, true)
+	if numFinished != calculator.numTrials {
+		t.Errorf("Expected a single batch of %d trials, found %d", calculator.numTrials, numFinished)
+	}
+	calculator.SetProgressFunc(nil)
+
+	// only the time it took to run the trials can differ
+	summary := calculator.Summarize(behavior)
+	summary.MeanElapsedTime = results.Summary.MeanElapsedTime
+	if !reflect.DeepEqual(results.Summary, summary) {
+		t.Error("Expected the summary to match Summarize")
+	}
+	if !reflect.DeepEqual(results.Groups, calculator.GroupFitness(behavior)) {
+		t.Errorf("Expected the groups to match GroupFitness, found %+v", results.Groups)
+	}
+	if !reflect.DeepEqual(results.Curves, calculator.EpidemicCurves(behavior)) {
+		t.Error("Expected the curves to match EpidemicCurves")
+	}
+	if results.AverageR0 <= 0 {
+		t.Errorf("Expected the initial infections to spread, found an R0 of %f", results.AverageR0)
+	}
+	if calculator.RunBatch(behavior, false).Curves != nil {
+		t.Error("Expected no curves unless they are asked for")
+	}
+
+	plotName := filepath.Join(t.TempDir(), "r0")
+	if err := results.SaveR0Plot(plotName); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(plotName + ".png"); err != nil {
+		t.Errorf("Expected the plot to be saved: %v", err)
+	}
+}
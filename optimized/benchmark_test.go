@@ -0,0 +1,34 @@
+package optimized
+
+import (
+	"fmt"
+	"testing"
+
+	dsnet "github.com/GaudiestTooth17/infection-resistant-network/diseasednetwork"
+	"github.com/GaudiestTooth17/infection-resistant-network/dynamicnet"
+	"github.com/GaudiestTooth17/infection-resistant-network/networkgenerator"
+)
+
+// BenchmarkSimulation runs 50 steps of a simulation on random networks of the sizes we use most
+func BenchmarkSimulation(b *testing.B) {
+	behaviors := map[string]dynamicnet.AgentBehavior{
+		"static":   nil,
+		"adaptive": dynamicnet.NewSimpleBehavior(2, 20, .5, .05),
+	}
+	for _, numNodes := range []int{100, 10000} {
+		network := networkgenerator.MakeErdosRenyi(numNodes, 10/float64(numNodes-1), 1)
+		for _, name := range []string{"static", "adaptive"} {
+			b.Run(fmt.Sprintf("%d-%s", numNodes, name), func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					diseasedNet := dsnet.NewDiseasedNetwork(&network,
+						[]dsnet.Disease{dsnet.NewBasicDisease(2, 4, .1, dsnet.NewInfectN(numNodes/50))},
+						behaviors[name], []dsnet.Observer{}, int64(i))
+					for step := 0; step < 50; step++ {
+						diseasedNet.Step()
+					}
+					rateNetwork(diseasedNet)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,230 @@
+package optimized
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"image/color"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/GaudiestTooth17/infection-resistant-network/dynamicnet"
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/plotter"
+	"gonum.org/v1/plot/plotutil"
+	"gonum.org/v1/plot/vg"
+)
+
+// EpidemicCurve is the number of nodes in each compartment of one disease at the start and
+// after every step of a batch of trials
+type EpidemicCurve struct {
+	// Compartments are the names of the disease's compartments
+	Compartments []string
+	// counts is indexed by step, then by compartment and holds the count from every trial
+	// in ascending order
+	counts [][][]float64
+}
+
+// newEpidemicCurve collects the counts recorded in each trial, which are indexed by trial,
+// step and then compartment. Every trial must have the same number of steps.
+func newEpidemicCurve(compartments []string, trials [][][]int) EpidemicCurve {
+	curve := EpidemicCurve{Compartments: compartments}
+	if len(trials) == 0 {
+		return curve
+	}
+	curve.counts = make([][][]float64, len(trials[0]))
+	for step := range curve.counts {
+		curve.counts[step] = make([][]float64, len(compartments))
+		for compartment := range compartments {
+			counts := make([]float64, len(trials))
+			for trial, trialCounts := range trials {
+				counts[trial] = float64(trialCounts[step][compartment])
+			}
+			sort.Float64s(counts)
+			curve.counts[step][compartment] = counts
+		}
+	}
+	return curve
+}
+
+// NumSteps returns the number of steps in the curve, including step 0
+func (c EpidemicCurve) NumSteps() int {
+	return len(c.counts)
+}
+
+// Mean returns the mean number of nodes in each compartment, indexed by step and then compartment
+func (c EpidemicCurve) Mean() [][]float64 {
+	return c.summarize(func(counts []float64) float64 {
+		total := 0.0
+		for _, count := range counts {
+			total += count
+		}
+		return total / float64(len(counts))
+	})
+}
+
+// Median returns the median number of nodes in each compartment, indexed by step and then compartment
+func (c EpidemicCurve) Median() [][]float64 {
+	return c.Percentile(50)
+}
+
+// Percentile returns the pth percentile of the number of nodes in each compartment, indexed by
+// step and then compartment. p is between 0 and 100. Values between trials are interpolated.
+func (c EpidemicCurve) Percentile(p float64) [][]float64 {
+	return c.summarize(func(counts []float64) float64 {
+		return percentile(counts, p)
+	})
+}
+
+// summarize applies statistic to the counts from every trial for each step and compartment
+func (c EpidemicCurve) summarize(statistic func(counts []float64) float64) [][]float64 {
+	summary := make([][]float64, len(c.counts))
+	for step, compartments := range c.counts {
+		summary[step] = make([]float64, len(compartments))
+		for compartment, counts := range compartments {
+			summary[step][compartment] = statistic(counts)
+		}
+	}
+	return summary
+}
+
+// percentile finds the pth percentile of sorted with linear interpolation
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := p / 100 * float64(len(sorted)-1)
+	below := int(math.Floor(rank))
+	if below < 0 {
+		return sorted[0]
+	}
+	if below >= len(sorted)-1 {
+		return sorted[len(sorted)-1]
+	}
+	return sorted[below] + (rank-float64(below))*(sorted[below+1]-sorted[below])
+}
+
+// WriteCSV writes one row for every step and compartment with the mean, the median and each of
+// the percentiles. The columns are step, compartment, mean, median and then p followed by each
+// percentile, like p25.
+func (c EpidemicCurve) WriteCSV(w io.Writer, percentiles ...float64) error {
+	for _, p := range percentiles {
+		if p < 0 || p > 100 {
+			return fmt.Errorf("percentile %v is not between 0 and 100", p)
+		}
+	}
+	writer := csv.NewWriter(w)
+	header := []string{"step", "compartment", "mean", "median"}
+	for _, p := range percentiles {
+		header = append(header, "p"+strconv.FormatFloat(p, 'f', -1, 64))
+	}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	mean := c.Mean()
+	median := c.Median()
+	bands := make([][][]float64, len(percentiles))
+	for i, p := range percentiles {
+		bands[i] = c.Percentile(p)
+	}
+	for step := range c.counts {
+		for compartment, name := range c.Compartments {
+			row := []string{strconv.Itoa(step), name, formatFloat(mean[step][compartment]),
+				formatFloat(median[step][compartment])}
+			for _, band := range bands {
+				row = append(row, formatFloat(band[step][compartment]))
+			}
+			if err := writer.Write(row); err != nil {
+				return err
+			}
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+// SavePlot saves a png named plotName.png with the median of every compartment that was used
+// over time. The interquartile range of each compartment is shaded behind its line.
+func (c EpidemicCurve) SavePlot(plotName string) error {
+	p, err := plot.New()
+	if err != nil {
+		return err
+	}
+	p.Title.Text = plotName
+	p.X.Label.Text = "Time Step"
+	p.Y.Label.Text = "Nodes"
+
+	median := c.Median()
+	lower := c.Percentile(25)
+	upper := c.Percentile(75)
+	for compartment, name := range c.Compartments {
+		if !c.used(compartment) {
+			continue
+		}
+		// the band goes forward along the upper quartile and back along the lower one
+		band := make(plotter.XYs, 0, 2*len(c.counts))
+		line := make(plotter.XYs, len(c.counts))
+		for step := range c.counts {
+			band = append(band, plotter.XY{X: float64(step), Y: upper[step][compartment]})
+			line[step] = plotter.XY{X: float64(step), Y: median[step][compartment]}
+		}
+		for step := len(c.counts) - 1; step >= 0; step-- {
+			band = append(band, plotter.XY{X: float64(step), Y: lower[step][compartment]})
+		}
+
+		lineColor := plotutil.Color(compartment)
+		polygon, err := plotter.NewPolygon(band)
+		if err != nil {
+			return err
+		}
+		polygon.Color = translucent(lineColor)
+		polygon.LineStyle.Width = 0
+		lines, err := plotter.NewLine(line)
+		if err != nil {
+			return err
+		}
+		lines.Color = lineColor
+		p.Add(polygon, lines)
+		p.Legend.Add(name, lines)
+	}
+	return p.Save(8*vg.Inch, 8*vg.Inch, plotName+".png")
+}
+
+// used reports whether any trial ever had a node in compartment
+func (c EpidemicCurve) used(compartment int) bool {
+	for _, compartments := range c.counts {
+		counts := compartments[compartment]
+		if counts[len(counts)-1] > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// translucent returns a version of c that lets the lines behind it show through
+func translucent(c color.Color) color.Color {
+	r, g, b, _ := c.RGBA()
+	return color.NRGBA{R: uint8(r >> 8), G: uint8(g >> 8), B: uint8(b >> 8), A: 64}
+}
+
+// EpidemicCurves runs a batch of simulations with the agents following behavior and returns the
+// epidemic curve of every disease. Pass a nil behavior to use the static network.
+func (n NetworkFitnessCalculator) EpidemicCurves(behavior dynamicnet.AgentBehavior) []EpidemicCurve {
+	curves, _ := n.EpidemicCurvesContext(context.Background(), behavior)
+	return curves
+}
+
+// EpidemicCurvesContext is EpidemicCurves, but it stops early and returns
+// the context's error if ctx is cancelled
+func (n NetworkFitnessCalculator) EpidemicCurvesContext(ctx context.Context,
+	behavior dynamicnet.AgentBehavior) ([]EpidemicCurve, error) {
+	results, err := n.RunBatchContext(ctx, behavior, true)
+	return results.Curves, err
+}
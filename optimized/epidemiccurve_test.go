@@ -0,0 +1,83 @@
+package optimized
+
+import (
+	"bytes"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// makeCurve makes a curve with a single step where S has the counts 1 through 4 in four trials
+// and I has the counts 4 through 1
+func makeCurve() EpidemicCurve {
+	return newEpidemicCurve([]string{"S", "I"}, [][][]int{
+		{{3, 2}}, {{1, 4}}, {{4, 1}}, {{2, 3}},
+	})
+}
+
+func TestEpidemicCurveStatistics(t *testing.T) {
+	curve := makeCurve()
+	tests := []struct {
+		name     string
+		found    [][]float64
+		expected [][]float64
+	}{
+		{"mean", curve.Mean(), [][]float64{{2.5, 2.5}}},
+		{"median", curve.Median(), [][]float64{{2.5, 2.5}}},
+		{"25th percentile", curve.Percentile(25), [][]float64{{1.75, 1.75}}},
+		{"minimum", curve.Percentile(0), [][]float64{{1, 1}}},
+		{"maximum", curve.Percentile(100), [][]float64{{4, 4}}},
+	}
+	for _, test := range tests {
+		if !reflect.DeepEqual(test.found, test.expected) {
+			t.Errorf("Expected a %s of %v, found %v", test.name, test.expected, test.found)
+		}
+	}
+}
+
+func TestEpidemicCurveCSV(t *testing.T) {
+	var buffer bytes.Buffer
+	if err := makeCurve().WriteCSV(&buffer, 25, 97.5); err != nil {
+		t.Fatal(err)
+	}
+	expected := "step,compartment,mean,median,p25,p97.5\n0,S,2.5,2.5,1.75,3.925\n0,I,2.5,2.5,1.75,3.925\n"
+	if buffer.String() != expected {
+		t.Errorf("Expected\n%s\nfound\n%s", expected, buffer.String())
+	}
+	if err := makeCurve().WriteCSV(&buffer, 101); err == nil {
+		t.Error("Expected a percentile over 100 to be an error")
+	}
+}
+
+// TestEpidemicCurves makes sure the curves agree with the fitness of the same batch
+func TestEpidemicCurves(t *testing.T) {
+	calculator := makeCalculator(3)
+	curves := calculator.EpidemicCurves(nil)
+	if len(curves) != 1 || curves[0].NumSteps() != 51 {
+		t.Fatalf("Expected 1 curve with 51 steps, found %d", len(curves))
+	}
+	mean := curves[0].Mean()
+	for step, counts := range mean {
+		total := 0.0
+		for _, count := range counts {
+			total += count
+		}
+		if math.Abs(total-200) > 1e-9 {
+			t.Fatalf("Expected the compartments to hold all 200 nodes in step %d, found %f", step, total)
+		}
+	}
+	fitness := calculator.BehaviorFitness(nil)
+	if math.Abs(mean[50][0]/200-float64(fitness)) > 1e-5 {
+		t.Errorf("Expected the final proportion susceptible to be %f, found %f", fitness, mean[50][0]/200)
+	}
+
+	plotName := filepath.Join(t.TempDir(), "curve")
+	if err := curves[0].SavePlot(plotName); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(plotName + ".png"); err != nil {
+		t.Errorf("Expected the plot to be saved: %v", err)
+	}
+}
@@ -1,113 +1,203 @@
 package optimized
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"time"
 
 	"github.com/GaudiestTooth17/infection-resistant-network/diseasednetwork"
 	dsnet "github.com/GaudiestTooth17/infection-resistant-network/diseasednetwork"
 	"github.com/GaudiestTooth17/infection-resistant-network/dynamicnet"
 	"github.com/GaudiestTooth17/infection-resistant-network/evolution"
-	"gonum.org/v1/plot"
-	"gonum.org/v1/plot/plotter"
-	"gonum.org/v1/plot/plotutil"
-	"gonum.org/v1/plot/vg"
+	"github.com/GaudiestTooth17/infection-resistant-network/trace"
 )
 
-// NetworkFitnessCalculator implements manager.FitnessCalculator and
-// measures the fitness of an agent behavior on a network
+// NetworkFitnessCalculator implements evolution.FitnessCalculator and
+// measures the fitness of an agent behavior on a network.
+// Trials are run on a pool of numWorkers goroutines.
 type NetworkFitnessCalculator struct {
-	network   dsnet.Network
-	numTrials int
-	simLength int
-	disease   diseasednetwork.Disease
-	r0        float64
+	network       dsnet.Network
+	numTrials     int
+	simLength     int
+	disease       diseasednetwork.Disease
+	seed          int64
+	r0            float64
+	numWorkers    int
+	progress      ProgressFunc
+	engine        dsnet.Engine
+	transmission  dsnet.TransmissionFunc
+	interventions []dsnet.Intervention
+	importations  []dsnet.Importation
+	// outbreakThreshold is the proportion of nodes that must be infected for a major outbreak
+	outbreakThreshold float64
+	// targetWidth, batchSize and maxTrials control adaptive trials (see SetAdaptiveTrials)
+	targetWidth float64
+	batchSize   int
+	maxTrials   int
 }
 
-// NewNetworkFitnessCalculator creates a NetworkFitnessCalculator with the provided values
-func NewNetworkFitnessCalculator(network dsnet.Network, numTrials, simLength int, disease dsnet.Disease) NetworkFitnessCalculator {
+// NewNetworkFitnessCalculator creates a NetworkFitnessCalculator with the provided values.
+// seed is the master seed. Each trial gets its own seed derived from it, so a whole batch
+// can be replayed by using the same master seed again.
+func NewNetworkFitnessCalculator(network dsnet.Network, numTrials, simLength int, disease dsnet.Disease,
+	seed int64) NetworkFitnessCalculator {
 	return NetworkFitnessCalculator{
-		network:   network,
-		numTrials: numTrials,
-		simLength: simLength,
-		disease:   disease,
-		r0:        -1,
+		network:           network,
+		numTrials:         numTrials,
+		simLength:         simLength,
+		disease:           disease,
+		seed:              seed,
+		r0:                -1,
+		outbreakThreshold: .1,
 	}
 }
 
-// CalculateFitness - Calculate how fit the parameters are as agent behaviors for a DiseasedNetwork
-func (n NetworkFitnessCalculator) CalculateFitness() float32 {
-	trialFitnesses := make([]float32, n.numTrials)
-	fitnessChannel := make(chan FitnessData)
-	for trial := 0; trial < n.numTrials; trial++ {
-		network := dsnet.NewDiseasedNetwork(&n.network,
-			[]dsnet.Disease{n.disease.MakeCopy()}, []dsnet.PlotMaker{})
-		go calcAsync(fitnessChannel, trial, network, n.simLength)
+// Seed returns the master seed of the fitness calculator
+func (n NetworkFitnessCalculator) Seed() int64 {
+	return n.seed
+}
+
+// SetEngine chooses how the simulations move through time. The default is dsnet.DiscreteEngine.
+// Running the same batch with dsnet.GillespieEngine is a way to check the discrete results
+// against continuous time.
+func (n *NetworkFitnessCalculator) SetEngine(engine dsnet.Engine) {
+	n.engine = engine
+}
+
+// SetTransmission chooses how edge weights change the chance of infection. If it is never set,
+// or set to nil, the simulations use dsnet.IndependentContacts.
+func (n *NetworkFitnessCalculator) SetTransmission(transmission dsnet.TransmissionFunc) {
+	n.transmission = transmission
+}
+
+// SetNodeAttributes gives each node its own susceptibility, infectiousness and group.
+// attributes must have an entry for every node in the network. nil makes every node the same.
+func (n *NetworkFitnessCalculator) SetNodeAttributes(attributes []dsnet.NodeAttributes) {
+	n.network.SetNodeAttributes(attributes)
+}
+
+// SetInterventions sets the vaccination campaigns that run during every trial. Vaccinated nodes
+// that are still protected count towards the fitness, so interventions can be compared
+// with agent behaviors.
+func (n *NetworkFitnessCalculator) SetInterventions(interventions []dsnet.Intervention) {
+	n.interventions = interventions
+}
+
+// SetImportations sets the ways infections are brought into the network during every trial
+func (n *NetworkFitnessCalculator) SetImportations(importations []dsnet.Importation) {
+	n.importations = importations
+}
+
+// newTrialNetwork makes the network for one trial with all of the calculator's settings
+func (n NetworkFitnessCalculator) newTrialNetwork(behavior dynamicnet.AgentBehavior, seed int64,
+	observers ...dsnet.Observer) dsnet.DiseasedNetwork {
+	return dsnet.New(&n.network, []dsnet.Disease{n.disease.MakeCopy()},
+		dsnet.WithSeed(seed),
+		dsnet.WithObservers(observers...),
+		dsnet.WithBehavior(behavior),
+		dsnet.WithEngine(n.engine),
+		dsnet.WithTransmission(n.transmission),
+		dsnet.WithImportations(n.importations...),
+		dsnet.WithInterventions(n.interventions...))
+}
+
+var _ evolution.ReseedableCalculator = NetworkFitnessCalculator{}
+
+// Reseed returns a copy of the calculator with a master seed derived from its own and stream.
+// evolution.PopulationManager uses it to run each generation on different trials.
+// The derived seeds come from negative stream numbers so they never match a trial's seed.
+func (n NetworkFitnessCalculator) Reseed(stream int) evolution.FitnessCalculator {
+	n.seed = dsnet.DeriveSeed(n.seed, -1-stream)
+	return n
+}
+
+// CalculateFitness - Calculate how fit the parameters are as agent behaviors for a DiseasedNetwork.
+// The genotype is converted to an AgentBehavior with genotypeToAgentBehavior.
+func (n NetworkFitnessCalculator) CalculateFitness(genotype evolution.Float32Genotype) float32 {
+	return n.BehaviorFitness(genotypeToAgentBehavior(genotype))
+}
+
+// BehaviorFitness runs numTrials simulations with the agents following behavior and
+// returns the average proportion of nodes left susceptible or protected by a vaccine,
+// which is between 0 and 1. If SetAdaptiveTrials was used, the number of trials depends on
+// how quickly the average settles down instead.
+// Pass a nil behavior to measure the fitness of the static network.
+func (n NetworkFitnessCalculator) BehaviorFitness(behavior dynamicnet.AgentBehavior) float32 {
+	// the background context is never cancelled, so there can't be an error
+	fitness, _ := n.BehaviorFitnessContext(context.Background(), behavior)
+	return fitness
+}
+
+// BehaviorFitnessContext is BehaviorFitness, but it stops early and returns
+// the context's error if ctx is cancelled
+func (n NetworkFitnessCalculator) BehaviorFitnessContext(ctx context.Context,
+	behavior dynamicnet.AgentBehavior) (float32, error) {
+	if n.adaptive() {
+		summary, err := n.SummarizeContext(ctx, behavior)
+		return float32(summary.Mean), err
 	}
-	for i := 0; i < n.numTrials; i++ {
-		fData := <-fitnessChannel
-		trialFitnesses[fData.trialNumber] = fData.fitness
+	trialFitnesses := make([]float32, n.numTrials)
+	err := n.runTrials(ctx, 0, n.numTrials, func(ctx context.Context, trial int) error {
+		network := n.newTrialNetwork(behavior, dsnet.DeriveSeed(n.seed, trial))
+		fData, err := calcTrial(ctx, trial, &network, n.simLength)
+		trialFitnesses[trial] = fData.fitness
+		return err
+	})
+	if err != nil {
+		return 0, err
 	}
 
 	totalFitness := float32(0)
 	for _, fitness := range trialFitnesses {
 		totalFitness += fitness / float32(n.numTrials)
 	}
-	return totalFitness
+	return totalFitness, nil
 }
 
-// GraphAverageR0 runs a batch of simulations and then graphs R0 at each of the steps.
-// It returns the average of all entries in allR0s.
-func (n NetworkFitnessCalculator) GraphAverageR0(plotName string) float64 {
-	allR0s := make([]plotter.XYs, n.numTrials)
-	r0Channel := make(chan R0Data)
-	for trial := 0; trial < n.numTrials; trial++ {
-		network := dsnet.NewDiseasedNetwork(&n.network,
-			[]dsnet.Disease{n.disease.MakeCopy()},
-			[]dsnet.PlotMaker{dsnet.NewR0Plotter("r0", n.simLength)})
-		go r0Async(r0Channel, trial, network, n.simLength)
-	}
-	for i := 0; i < n.numTrials; i++ {
-		r0data := <-r0Channel
-		allR0s[r0data.trialNumber] = r0data.plotPoints
-	}
+// GroupSummary is the result of a batch of trials for the nodes in one group
+type GroupSummary struct {
+	Group    int
+	NumNodes int
+	// Susceptible is the average proportion of the group's nodes left susceptible or
+	// protected by a vaccine
+	Susceptible float32
+}
 
-	// TODO: make graph show interquartile ranges
-	// save the plot
-	p, err := plot.New()
-	check(err)
+// GroupFitness is BehaviorFitness broken down by the groups in the node attributes.
+// The summaries are sorted by group. Without node attributes every node is in group 0.
+func (n NetworkFitnessCalculator) GroupFitness(behavior dynamicnet.AgentBehavior) []GroupSummary {
+	summaries, _ := n.GroupFitnessContext(context.Background(), behavior)
+	return summaries
+}
 
-	p.Title.Text = plotName
-	p.X.Label.Text = "Time Step"
-	p.Y.Label.Text = "R0"
-	points := make(plotter.XYs, n.simLength)
-	// Set the points to be time step to average R0
-	for j := 0; j < n.simLength; j++ {
-		// calculate average R0
-		avgR0 := float64(0)
-		for i := 0; i < n.numTrials; i++ {
-			avgR0 += allR0s[i][j].Y / float64(n.numTrials)
-		}
-		// add average R0 to points
-		points[j].X = float64(j)
-		points[j].Y = avgR0
-	}
+// GroupFitnessContext is GroupFitness, but it stops early and returns
+// the context's error if ctx is cancelled
+func (n NetworkFitnessCalculator) GroupFitnessContext(ctx context.Context,
+	behavior dynamicnet.AgentBehavior) ([]GroupSummary, error) {
+	results, err := n.RunBatchContext(ctx, behavior, false)
+	return results.Groups, err
+}
 
-	// add the points to the plot and save the plot
-	err = plotutil.AddLines(p, plotName, points)
-	check(err)
-	err = p.Save(8*vg.Inch, 8*vg.Inch, plotName+".png")
+// GraphAverageR0 runs a batch of simulations and then graphs the effective reproduction number
+// of the cases that started in each step, pooled across all the trials.
+// It returns the pooled estimate of R0 from the initial infections (see InfectionTree.R0).
+// Use RunBatch to get the other results of the batch from the same trials.
+func (n NetworkFitnessCalculator) GraphAverageR0(plotName string) float64 {
+	// the background context is never cancelled, so an error means the plot couldn't be saved
+	averageR0, err := n.GraphAverageR0Context(context.Background(), plotName)
 	check(err)
+	return averageR0
+}
 
-	nonZeroEntries := float64(0)
-	avgR0 := float64(0)
-	for _, point := range points {
-		if point.Y > 0 {
-			nonZeroEntries++
-			avgR0 += point.Y
-		}
+// GraphAverageR0Context is GraphAverageR0, but it stops early and returns
+// the context's error if ctx is cancelled. Nothing is plotted in that case.
+func (n NetworkFitnessCalculator) GraphAverageR0Context(ctx context.Context, plotName string) (float64, error) {
+	results, err := n.RunBatchContext(ctx, nil, false)
+	if err != nil {
+		return 0, err
 	}
-	return avgR0 / nonZeroEntries
+	return results.AverageR0, results.SaveR0Plot(plotName)
 }
 
 // R0 of the disease that was given to the fitness calculator
@@ -116,31 +206,27 @@ func (n NetworkFitnessCalculator) R0() float64 {
 }
 
 // CalcAndOutput sequentially calculates the fitness of a network
-// and prints the change in states to the screen
-func (n *NetworkFitnessCalculator) CalcAndOutput() float32 {
+// and prints the change in states to the screen. It runs until no nodes are infected.
+// The observers watch the simulation, so a trace.Writer records the same run that is printed.
+func (n *NetworkFitnessCalculator) CalcAndOutput(observers ...dsnet.Observer) float32 {
 	// run simulations
-	network := dsnet.NewDiseasedNetwork(&n.network,
-		[]dsnet.Disease{n.disease.MakeCopy()}, []dsnet.PlotMaker{})
+	network := n.newTrialNetwork(nil, n.seed, observers...)
 	printStates(network.GetNodeStates(0))
 
 	// run simulation
-	sumR0 := float64(0)
-	numInfectiousSteps := float64(0)
-	for len(network.FindNodesInState(dsnet.StateE, 0))+len(network.FindNodesInState(dsnet.StateI, 0)) > 0 {
-		_, r0 := network.Step()
-		if r0 > 0 {
-			sumR0 += r0
-			numInfectiousSteps++
-		}
+	for network.NumInfected(0) > 0 {
+		network.Step()
 		printStates(network.GetNodeStates(0))
 	}
-	n.r0 = sumR0 / numInfectiousSteps
+	network.Finish()
+	n.r0 = network.R0(0)
 
 	fmt.Println("end")
 	return rateNetwork(network)
 }
 
-// For each node with a different state, prints "<node> <state>\n" to stdout. Finishes with a newline.
+// printStates prints "<node> <state>\n" to stdout for every node, which is what graph-visualizer
+// reads. Finishes with a newline. WriteTrace only records the changes.
 func printStates(states []uint8) {
 	for node, state := range states {
 		fmt.Printf("%d %d\n", node, state)
@@ -148,25 +234,45 @@ func printStates(states []uint8) {
 	fmt.Println()
 }
 
-// FitnessData conveys data about a fitness calculation over a channel
+// WriteTrace runs one simulation of simLength steps with the agents following behavior and
+// writes a trace of it to w (see the trace package). Like CalcAndOutput, it uses the master seed,
+// but it runs for simLength steps instead of until no nodes are infected. Pass a trace.Writer
+// to CalcAndOutput to trace the run it prints.
+func (n NetworkFitnessCalculator) WriteTrace(w io.Writer, behavior dynamicnet.AgentBehavior) error {
+	writer := trace.NewWriter(w)
+	network := n.newTrialNetwork(behavior, n.seed, writer)
+	if _, err := calcTrial(context.Background(), 0, &network, n.simLength); err != nil {
+		return err
+	}
+	return writer.Err()
+}
+
+// FitnessData holds data about a single fitness calculation
 type FitnessData struct {
 	trialNumber int
 	fitness     float32
 	elapsedTime time.Duration
 }
 
-func calcAsync(outChan chan<- FitnessData, trialNumber int, network dsnet.DiseasedNetwork, numSteps int) {
+// calcTrial runs one simulation. It checks ctx between steps and returns its error
+// if it has been cancelled. network is a pointer so the caller sees the network the steps
+// changed instead of a copy that shares its rows (see dsnet.Network).
+func calcTrial(ctx context.Context, trialNumber int, network *dsnet.DiseasedNetwork, numSteps int) (FitnessData, error) {
 	totalDuration := time.Duration(0)
 	for i := 0; i < numSteps; i++ {
+		if err := ctx.Err(); err != nil {
+			return FitnessData{}, err
+		}
 		duration, _ := network.Step()
 		totalDuration += duration
 	}
-	fitness := rateNetwork(network)
-	outChan <- FitnessData{trialNumber: trialNumber, fitness: fitness, elapsedTime: totalDuration}
+	network.Finish()
+	fitness := rateNetwork(*network)
+	return FitnessData{trialNumber: trialNumber, fitness: fitness, elapsedTime: totalDuration}, nil
 }
 
 func rateNetwork(network diseasednetwork.DiseasedNetwork) float32 {
-	susceptibleNodes := len(network.FindNodesInState(diseasednetwork.StateS, 0))
+	susceptibleNodes := network.NumInState(diseasednetwork.StateS, 0) + network.NumImmunized()
 	// exposedNodes := len(network.FindNodesInState(diseasednetwork.StateE))
 	// infectedNodes := len(network.FindNodesInState(diseasednetwork.StateI))
 	// removedNodes := len(network.FindNodesInState(diseasednetwork.StateR))
@@ -175,25 +281,9 @@ func rateNetwork(network diseasednetwork.DiseasedNetwork) float32 {
 	return float32(susceptibleNodes) / float32(totalNodes)
 }
 
-// R0Data is used to send data about R0 over a channel
-type R0Data struct {
-	trialNumber int
-	plotPoints  plotter.XYs
-	elapsedTime time.Duration
-}
-
-func r0Async(outChan chan<- R0Data, trialNumber int, network dsnet.DiseasedNetwork, numSteps int) {
-	duration := time.Duration(0)
-	for i := 0; i < numSteps; i++ {
-		d, _ := network.Step()
-		duration += d
-	}
-	// Watch out! This assumes that the r0 PlotMaker is the first in the slice.
-	outChan <- R0Data{trialNumber: trialNumber, plotPoints: network.PlotMakers[0].Points(),
-		elapsedTime: duration}
-}
-
-// genotypeToAgentBehavior converts a Float32Genotype to an AgentBehavior
+// genotypeToAgentBehavior converts a Float32Genotype to an AgentBehavior.
+// The genes are minConnections, maxConnections, removeInfectedNeighborProb and
+// addNeighborOfNeighborProb, in that order, just like the columns in genotypes.csv.
 func genotypeToAgentBehavior(genotype evolution.Float32Genotype) dynamicnet.AgentBehavior {
 	return dynamicnet.NewSimpleBehavior(int(genotype.Get(0)), int(genotype.Get(1)),
 		genotype.Get(2), genotype.Get(3))
@@ -0,0 +1,286 @@
+package optimized
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"math"
+	"os"
+	"testing"
+
+	dsnet "github.com/GaudiestTooth17/infection-resistant-network/diseasednetwork"
+	"github.com/GaudiestTooth17/infection-resistant-network/dynamicnet"
+	"github.com/GaudiestTooth17/infection-resistant-network/evolution"
+	"github.com/GaudiestTooth17/infection-resistant-network/networkgenerator"
+	"github.com/GaudiestTooth17/infection-resistant-network/trace"
+)
+
+func makeCalculator(seed int64) NetworkFitnessCalculator {
+	return NewNetworkFitnessCalculator(networkgenerator.MakeWattsStrogatz(200, 4, .1, 1), 20, 50,
+		dsnet.NewBasicDisease(2, 4, .3, dsnet.NewInfectN(3)), seed)
+}
+
+// TestReplay makes sure a batch of trials can be reproduced from the master seed
+func TestReplay(t *testing.T) {
+	behavior := dynamicnet.NewSimpleBehavior(1, 6, .5, .1)
+	fitness1 := makeCalculator(42).BehaviorFitness(behavior)
+	fitness2 := makeCalculator(42).BehaviorFitness(behavior)
+	if fitness1 != fitness2 {
+		t.Errorf("Expected identical fitnesses from the same seed, found %f and %f", fitness1, fitness2)
+	}
+
+	differentSeeds := false
+	for seed := int64(0); seed < 5 && !differentSeeds; seed++ {
+		differentSeeds = makeCalculator(seed).BehaviorFitness(behavior) != fitness1
+	}
+	if !differentSeeds {
+		t.Errorf("Expected different seeds to give different fitnesses")
+	}
+}
+
+// TestCalculateFitness makes sure a genotype is rated like the behavior its genes describe
+func TestCalculateFitness(t *testing.T) {
+	genes := []float32{2, 7, .4, .2}
+	fitness := makeCalculator(3).CalculateFitness(evolution.NewFloat32Genotype(genes))
+	expected := makeCalculator(3).BehaviorFitness(dynamicnet.NewSimpleBehavior(2, 7, .4, .2))
+	if fitness != expected {
+		t.Errorf("Expected the genotype to have the fitness of its behavior, %f, found %f", expected, fitness)
+	}
+	if fitness < 0 || fitness > 1 {
+		t.Errorf("Expected a fitness between 0 and 1, found %f", fitness)
+	}
+}
+
+func TestDeriveSeedIsIndependent(t *testing.T) {
+	seen := make(map[int64]bool)
+	for trial := 0; trial < 1000; trial++ {
+		seed := dsnet.DeriveSeed(42, trial)
+		if seen[seed] {
+			t.Fatalf("Trial %d repeated seed %d", trial, seed)
+		}
+		seen[seed] = true
+	}
+}
+
+// TestNumWorkersDoesNotChangeFitness makes sure the results only depend on the seed
+func TestNumWorkersDoesNotChangeFitness(t *testing.T) {
+	behavior := dynamicnet.NewSimpleBehavior(1, 6, .5, .1)
+	calculator := makeCalculator(7)
+	calculator.SetNumWorkers(1)
+	serialFitness := calculator.BehaviorFitness(behavior)
+	calculator.SetNumWorkers(8)
+	parallelFitness := calculator.BehaviorFitness(behavior)
+	if serialFitness != parallelFitness {
+		t.Errorf("Expected 1 and 8 workers to agree, found %f and %f", serialFitness, parallelFitness)
+	}
+}
+
+func TestProgress(t *testing.T) {
+	calculator := makeCalculator(7)
+	calculator.SetNumWorkers(3)
+	lastFinished := 0
+	calculator.SetProgressFunc(func(finished, total int) {
+		if finished != lastFinished+1 || total != calculator.numTrials {
+			t.Errorf("Expected progress %d/%d, found %d/%d", lastFinished+1, calculator.numTrials, finished, total)
+		}
+		lastFinished = finished
+	})
+	calculator.BehaviorFitness(nil)
+	if lastFinished != calculator.numTrials {
+		t.Errorf("Expected progress to reach %d trials, reached %d", calculator.numTrials, lastFinished)
+	}
+}
+
+func TestCancel(t *testing.T) {
+	calculator := makeCalculator(7)
+	calculator.SetNumWorkers(1)
+	ctx, cancel := context.WithCancel(context.Background())
+	calculator.SetProgressFunc(func(finished, total int) {
+		if finished == 2 {
+			cancel()
+		}
+	})
+	_, err := calculator.BehaviorFitnessContext(ctx, nil)
+	if err != context.Canceled {
+		t.Errorf("Expected %v, found %v", context.Canceled, err)
+	}
+}
+
+// TestGillespieEngine makes sure the engine can be chosen and is replayed from the seed like the discrete one
+func TestGillespieEngine(t *testing.T) {
+	calculator1 := makeCalculator(42)
+	discrete := calculator1.BehaviorFitness(nil)
+	calculator1.SetEngine(dsnet.GillespieEngine)
+	calculator2 := makeCalculator(42)
+	calculator2.SetEngine(dsnet.GillespieEngine)
+	fitness1 := calculator1.BehaviorFitness(nil)
+	fitness2 := calculator2.BehaviorFitness(nil)
+	if fitness1 != fitness2 {
+		t.Errorf("Expected identical fitnesses from the same seed, found %f and %f", fitness1, fitness2)
+	}
+	if fitness1 == discrete {
+		t.Errorf("Expected the engines to give different results, both gave %f", discrete)
+	}
+}
+
+func TestGroupFitness(t *testing.T) {
+	behavior := dynamicnet.NewSimpleBehavior(1, 6, .5, .1)
+	calculator := makeCalculator(7)
+	summaries := calculator.GroupFitness(behavior)
+	if len(summaries) != 1 || summaries[0].Group != 0 || summaries[0].NumNodes != 200 {
+		t.Fatalf("Expected every node to be in group 0, found %+v", summaries)
+	}
+	fitness := calculator.BehaviorFitness(behavior)
+	if math.Abs(float64(summaries[0].Susceptible-fitness)) > 1e-5 {
+		t.Errorf("Expected a single group to match the fitness %f, found %f", fitness, summaries[0].Susceptible)
+	}
+
+	// nodes in group 1 can't be infected, although they can still be chosen as initial infections
+	attributes := dsnet.DefaultAttributes(200)
+	for node := 0; node < 100; node++ {
+		attributes[node].Susceptibility = 0
+		attributes[node].Group = 1
+	}
+	calculator.SetNodeAttributes(attributes)
+	summaries = calculator.GroupFitness(behavior)
+	if len(summaries) != 2 || summaries[0].Group != 0 || summaries[1].Group != 1 {
+		t.Fatalf("Expected groups 0 and 1 in order, found %+v", summaries)
+	}
+	if summaries[1].Susceptible < 1-3./100 || summaries[0].Susceptible >= summaries[1].Susceptible {
+		t.Errorf("Expected group 1 to be protected, found %+v", summaries)
+	}
+}
+
+// TestInterventions checks that vaccinating the best connected nodes beats doing nothing
+func TestInterventions(t *testing.T) {
+	calculator := makeCalculator(7)
+	withoutVaccines := calculator.BehaviorFitness(nil)
+	calculator.SetInterventions([]dsnet.Intervention{
+		dsnet.NewIntervention(dsnet.NewDegreeTargeting(), 60, .9),
+	})
+	withVaccines := calculator.BehaviorFitness(nil)
+	if withVaccines <= withoutVaccines {
+		t.Errorf("Expected vaccines to help, found a fitness of %f with them and %f without",
+			withVaccines, withoutVaccines)
+	}
+	if replay := calculator.BehaviorFitness(nil); replay != withVaccines {
+		t.Errorf("Expected the same fitness from the same seed, found %f and %f", withVaccines, replay)
+	}
+}
+
+// TestVaccinesWithoutImmuneCompartment makes sure useless doses don't count nodes twice
+func TestVaccinesWithoutImmuneCompartment(t *testing.T) {
+	model := dsnet.SISModel(dsnet.NewFixedDuration(4))
+	model.Immune = dsnet.StateS
+	disease, err := dsnet.NewCompartmentalDisease(model, 0, dsnet.NewInfectN(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	calculator := NewNetworkFitnessCalculator(networkgenerator.MakeWattsStrogatz(200, 4, .1, 1), 20, 50,
+		disease, 7)
+	calculator.SetInterventions([]dsnet.Intervention{
+		dsnet.NewIntervention(dsnet.NewRandomTargeting(), 150, 1),
+	})
+	if summary := calculator.Summarize(nil); summary.Max > 1 {
+		t.Errorf("Expected every trial to have a fitness of at most 1, found %f", summary.Max)
+	}
+}
+
+// TestImportations checks that repeated introductions leave fewer nodes susceptible
+func TestImportations(t *testing.T) {
+	behavior := dynamicnet.NewSimpleBehavior(1, 6, .5, .1)
+	calculator := makeCalculator(7)
+	singleSpark := calculator.BehaviorFitness(behavior)
+	calculator.SetImportations([]dsnet.Importation{dsnet.NewPulseImportation(3, 10, 10, 4)})
+	repeated := calculator.BehaviorFitness(behavior)
+	if repeated >= singleSpark {
+		t.Errorf("Expected importations to lower the fitness, found %f with them and %f without",
+			repeated, singleSpark)
+	}
+	if replay := calculator.BehaviorFitness(behavior); replay != repeated {
+		t.Errorf("Expected the same fitness from the same seed, found %f and %f", repeated, replay)
+	}
+}
+
+// TestWriteTrace makes sure the trace of a run can be replayed to its end
+func TestWriteTrace(t *testing.T) {
+	var buffer bytes.Buffer
+	if err := makeCalculator(2).WriteTrace(&buffer, dynamicnet.NewSimpleBehavior(1, 6, .5, .1)); err != nil {
+		t.Fatal(err)
+	}
+	reader, err := trace.NewReader(&buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	replay := trace.NewReplay(reader.Header())
+	for {
+		event, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := replay.Apply(event); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !replay.Ended() || replay.Step() != 50 {
+		t.Errorf("Expected the trace to end after step 50, found %t and %d", replay.Ended(), replay.Step())
+	}
+}
+
+// TestReseed makes sure reseeded calculators run different trials that can still be replayed
+func TestReseed(t *testing.T) {
+	genotype := evolution.NewFloat32Genotype([]float32{1, 6, .5, .1})
+	calculator := makeCalculator(42)
+	original := calculator.CalculateFitness(genotype)
+	first := calculator.Reseed(0).CalculateFitness(genotype)
+	if first == original || first == calculator.Reseed(1).CalculateFitness(genotype) {
+		t.Errorf("Expected each stream to run different trials, found %f for stream 0", first)
+	}
+	if replay := makeCalculator(42).Reseed(0).CalculateFitness(genotype); replay != first {
+		t.Errorf("Expected the same stream to give the same fitness, found %f and %f", first, replay)
+	}
+}
+
+// TestTraceCalcAndOutput makes sure the trace of CalcAndOutput ends when the printed run does
+func TestTraceCalcAndOutput(t *testing.T) {
+	devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer devNull.Close()
+	stdout := os.Stdout
+	os.Stdout = devNull
+	defer func() { os.Stdout = stdout }()
+
+	var buffer bytes.Buffer
+	writer := trace.NewWriter(&buffer)
+	calculator := makeCalculator(2)
+	calculator.CalcAndOutput(writer)
+	if writer.Err() != nil {
+		t.Fatal(writer.Err())
+	}
+	reader, err := trace.NewReader(&buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	replay := trace.NewReplay(reader.Header())
+	for event, err := reader.Next(); err != io.EOF; event, err = reader.Next() {
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := replay.Apply(event); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for node, state := range replay.NodeStates(0) {
+		if state == dsnet.StateE || state == dsnet.StateI {
+			t.Fatalf("Expected the trace to end once no nodes were infected, but node %d is in state %d", node, state)
+		}
+	}
+	if !replay.Ended() || replay.Step() == 0 || replay.Step() == uint(calculator.simLength) {
+		t.Errorf("Expected the trace to end when the infections did, not after %d steps", replay.Step())
+	}
+}
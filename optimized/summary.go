@@ -0,0 +1,235 @@
+package optimized
+
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
+
+	dsnet "github.com/GaudiestTooth17/infection-resistant-network/diseasednetwork"
+	"github.com/GaudiestTooth17/infection-resistant-network/dynamicnet"
+	"github.com/GaudiestTooth17/infection-resistant-network/evolution"
+)
+
+// z95 is the number of standard errors on either side of the mean in a 95% confidence interval
+// when the standard error is known
+const z95 = 1.959964
+
+// tTable holds the 97.5th percentile of Student's t distribution for 1 through 30 degrees of freedom
+var tTable = [...]float64{12.706205, 4.302653, 3.182446, 2.776445, 2.570582, 2.446912, 2.364624,
+	2.306004, 2.262157, 2.228139, 2.200985, 2.178813, 2.160369, 2.144787, 2.131450, 2.119905,
+	2.109816, 2.100922, 2.093024, 2.085963, 2.079614, 2.073873, 2.068658, 2.063899, 2.059539,
+	2.055529, 2.051831, 2.048407, 2.045230, 2.042272}
+
+// t95 is the number of standard errors on either side of the mean in a 95% confidence interval
+// when the standard error is estimated with df degrees of freedom. It comes from Student's t
+// distribution, which is wider than the normal distribution for small samples. Past the table
+// it uses the Cornish-Fisher expansion around z95. df below 1 is treated as 1.
+func t95(df int) float64 {
+	if df < 1 {
+		df = 1
+	}
+	if df <= len(tTable) {
+		return tTable[df-1]
+	}
+	z := z95
+	v := float64(df)
+	return z + (z*z*z+z)/(4*v) + (5*math.Pow(z, 5)+16*z*z*z+3*z)/(96*v*v) +
+		(3*math.Pow(z, 7)+19*math.Pow(z, 5)+17*z*z*z-15*z)/(384*v*v*v)
+}
+
+// FitnessSummary describes the results of a batch of trials. The fitness of a trial is the
+// proportion of nodes left susceptible or protected by a vaccine, just like BehaviorFitness.
+// Everything about outbreaks is measured on the bad disease.
+type FitnessSummary struct {
+	NumTrials int
+	Mean      float64
+	// Variance is the sample variance of the fitnesses
+	Variance float64
+	// StdError is the standard error of the mean
+	StdError float64
+	// ConfidenceLow and ConfidenceHigh bound a 95% confidence interval for the mean.
+	// It uses Student's t distribution, so small batches get wider intervals. It still assumes
+	// the fitnesses aren't too skewed, which is safest with batches of 30 or more trials.
+	ConfidenceLow  float64
+	ConfidenceHigh float64
+	Min            float64
+	Max            float64
+	Median         float64
+	LowerQuartile  float64
+	UpperQuartile  float64
+	// MajorOutbreakProbability is the proportion of trials where the infections, counting the
+	// initial ones, reached more than the outbreak threshold of nodes (see
+	// SetMajorOutbreakThreshold). The outbreaks in the other trials went extinct early.
+	MajorOutbreakProbability float64
+	// MeanDuration is the mean number of steps until no nodes were infected. Trials that
+	// still had infected nodes at the end count as lasting the whole simulation.
+	MeanDuration float64
+	// MeanPeakPrevalence is the mean of the largest proportion of nodes infected at once
+	MeanPeakPrevalence float64
+	// MeanTimeToPeak is the mean step that the peak prevalence was first reached in
+	MeanTimeToPeak float64
+	// MeanElapsedTime is the mean time it took to run a trial
+	MeanElapsedTime time.Duration
+	// TargetReached reports whether the confidence interval is no wider than the target set by
+	// SetAdaptiveTrials. It is always false if the number of trials isn't adaptive.
+	TargetReached bool
+	// fitnesses holds the fitness of every trial in ascending order
+	fitnesses []float64
+	// trialFitnesses holds the fitness of every trial in the order the trials were run
+	trialFitnesses []float64
+	// seed is the master seed of the calculator the trials came from if seeded is true
+	seed   int64
+	seeded bool
+}
+
+// Percentile returns the pth percentile of the fitnesses, where p is between 0 and 100.
+// Values between trials are interpolated.
+func (s FitnessSummary) Percentile(p float64) float64 {
+	return percentile(s.fitnesses, p)
+}
+
+// ConfidenceWidth returns the width of the 95% confidence interval for the mean
+func (s FitnessSummary) ConfidenceWidth() float64 {
+	return s.ConfidenceHigh - s.ConfidenceLow
+}
+
+// Difference returns the difference between the mean fitness of s and other along with a 95%
+// confidence interval for it. If the interval doesn't contain 0, the difference is unlikely to
+// be due to chance.
+// If both summaries have the same number of trials from calculators with the same master seed,
+// trial i of each used the same random numbers, so the trials are compared in pairs. That cancels
+// out the luck the two share and gives a narrower interval. Otherwise the summaries are treated
+// as independent and the interval uses the normal approximation, so it needs 30 or more trials
+// in each.
+func (s FitnessSummary) Difference(other FitnessSummary) (difference, low, high float64) {
+	difference = s.Mean - other.Mean
+	if s.seeded && other.seeded && s.seed == other.seed && s.NumTrials == other.NumTrials && s.NumTrials > 1 {
+		differences := make([]float64, s.NumTrials)
+		for trial := range differences {
+			differences[trial] = s.trialFitnesses[trial] - other.trialFitnesses[trial]
+		}
+		stdError := math.Sqrt(sampleVariance(differences, difference) / float64(s.NumTrials))
+		return difference, difference - t95(s.NumTrials-1)*stdError, difference + t95(s.NumTrials-1)*stdError
+	}
+	stdError := math.Sqrt(s.StdError*s.StdError + other.StdError*other.StdError)
+	return difference, difference - z95*stdError, difference + z95*stdError
+}
+
+// SetMajorOutbreakThreshold sets the proportion of nodes that must be infected for an outbreak
+// to count as major in a FitnessSummary. The default is .1.
+func (n *NetworkFitnessCalculator) SetMajorOutbreakThreshold(threshold float64) {
+	n.outbreakThreshold = threshold
+}
+
+// outbreakObserver follows the prevalence of the bad disease through a trial
+type outbreakObserver struct {
+	dsnet.BaseObserver
+	peak       int
+	timeToPeak uint
+	// duration is the first step without any infected nodes or 0 if there hasn't been one
+	duration uint
+}
+
+func (o *outbreakObserver) RunStarted(n *dsnet.DiseasedNetwork) {
+	o.peak = n.NumInfected(0)
+}
+
+func (o *outbreakObserver) StepEnded(n *dsnet.DiseasedNetwork, step uint) {
+	infected := n.NumInfected(0)
+	if infected > o.peak {
+		o.peak = infected
+		o.timeToPeak = step
+	}
+	if infected == 0 && o.duration == 0 {
+		o.duration = step
+	}
+}
+
+// trialOutcome is what a FitnessSummary needs to know about one trial
+type trialOutcome struct {
+	fitness       float64
+	elapsedTime   time.Duration
+	majorOutbreak bool
+	duration      uint
+	peak          float64
+	timeToPeak    uint
+}
+
+// Summarize runs numTrials simulations with the agents following behavior and describes how
+// fit it is. See SetAdaptiveTrials for running as many trials as it takes to reach a precision
+// instead. Pass a nil behavior to summarize the static network.
+func (n NetworkFitnessCalculator) Summarize(behavior dynamicnet.AgentBehavior) FitnessSummary {
+	summary, _ := n.SummarizeContext(context.Background(), behavior)
+	return summary
+}
+
+// SummarizeGenotype is Summarize for the behavior the genotype stands for.
+// See CalculateFitness.
+func (n NetworkFitnessCalculator) SummarizeGenotype(genotype evolution.Float32Genotype) FitnessSummary {
+	return n.Summarize(genotypeToAgentBehavior(genotype))
+}
+
+// SummarizeContext is Summarize, but it stops early and returns
+// the context's error if ctx is cancelled
+func (n NetworkFitnessCalculator) SummarizeContext(ctx context.Context,
+	behavior dynamicnet.AgentBehavior) (FitnessSummary, error) {
+	results, err := n.RunBatchContext(ctx, behavior, false)
+	return results.Summary, err
+}
+
+// summarize computes the statistics of a batch of trials
+func summarize(outcomes []trialOutcome) FitnessSummary {
+	summary := FitnessSummary{NumTrials: len(outcomes), fitnesses: make([]float64, len(outcomes)),
+		trialFitnesses: make([]float64, len(outcomes))}
+	if len(outcomes) == 0 {
+		return summary
+	}
+	trials := float64(len(outcomes))
+	var totalFitness, numMajor, totalDuration, totalPeak, totalTimeToPeak float64
+	elapsedTime := time.Duration(0)
+	for i, outcome := range outcomes {
+		summary.fitnesses[i] = outcome.fitness
+		summary.trialFitnesses[i] = outcome.fitness
+		totalFitness += outcome.fitness
+		if outcome.majorOutbreak {
+			numMajor++
+		}
+		totalDuration += float64(outcome.duration)
+		totalPeak += outcome.peak
+		totalTimeToPeak += float64(outcome.timeToPeak)
+		elapsedTime += outcome.elapsedTime
+	}
+	summary.Mean = totalFitness / trials
+	summary.MajorOutbreakProbability = numMajor / trials
+	summary.MeanDuration = totalDuration / trials
+	summary.MeanPeakPrevalence = totalPeak / trials
+	summary.MeanTimeToPeak = totalTimeToPeak / trials
+	summary.MeanElapsedTime = elapsedTime / time.Duration(len(outcomes))
+
+	summary.Variance = sampleVariance(summary.fitnesses, summary.Mean)
+	summary.StdError = math.Sqrt(summary.Variance / trials)
+	summary.ConfidenceLow = summary.Mean - t95(len(outcomes)-1)*summary.StdError
+	summary.ConfidenceHigh = summary.Mean + t95(len(outcomes)-1)*summary.StdError
+
+	sort.Float64s(summary.fitnesses)
+	summary.Min = summary.fitnesses[0]
+	summary.Max = summary.fitnesses[len(outcomes)-1]
+	summary.Median = summary.Percentile(50)
+	summary.LowerQuartile = summary.Percentile(25)
+	summary.UpperQuartile = summary.Percentile(75)
+	return summary
+}
+
+// sampleVariance returns the sample variance of values, which have the given mean.
+// It is 0 if there are fewer than two values.
+func sampleVariance(values []float64, mean float64) float64 {
+	if len(values) < 2 {
+		return 0
+	}
+	variance := 0.0
+	for _, value := range values {
+		variance += (value - mean) * (value - mean) / float64(len(values)-1)
+	}
+	return variance
+}
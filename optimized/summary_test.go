@@ -0,0 +1,136 @@
+package optimized
+
+import (
+	"math"
+	"testing"
+
+	dsnet "github.com/GaudiestTooth17/infection-resistant-network/diseasednetwork"
+	"github.com/GaudiestTooth17/infection-resistant-network/dynamicnet"
+	"github.com/GaudiestTooth17/infection-resistant-network/evolution"
+	"github.com/GaudiestTooth17/infection-resistant-network/networkgenerator"
+)
+
+func TestSummarizeStatistics(t *testing.T) {
+	summary := summarize([]trialOutcome{
+		{fitness: .8, duration: 10, peak: .1, timeToPeak: 2},
+		{fitness: .2, majorOutbreak: true, duration: 30, peak: .5, timeToPeak: 12},
+		{fitness: .6, duration: 10, peak: .1, timeToPeak: 2},
+		{fitness: .4, majorOutbreak: true, duration: 30, peak: .3, timeToPeak: 8},
+	})
+	variance := .2 / 3
+	tests := []struct {
+		name     string
+		found    float64
+		expected float64
+	}{
+		{"mean", summary.Mean, .5},
+		{"variance", summary.Variance, variance},
+		{"standard error", summary.StdError, math.Sqrt(variance / 4)},
+		{"low end of the confidence interval", summary.ConfidenceLow, .5 - 3.182446*math.Sqrt(variance/4)},
+		{"high end of the confidence interval", summary.ConfidenceHigh, .5 + 3.182446*math.Sqrt(variance/4)},
+		{"minimum", summary.Min, .2},
+		{"maximum", summary.Max, .8},
+		{"median", summary.Median, .5},
+		{"lower quartile", summary.LowerQuartile, .35},
+		{"upper quartile", summary.UpperQuartile, .65},
+		{"90th percentile", summary.Percentile(90), .74},
+		{"major outbreak probability", summary.MajorOutbreakProbability, .5},
+		{"mean duration", summary.MeanDuration, 20},
+		{"mean peak prevalence", summary.MeanPeakPrevalence, .25},
+		{"mean time to peak", summary.MeanTimeToPeak, 6},
+	}
+	for _, test := range tests {
+		if math.Abs(test.found-test.expected) > 1e-9 {
+			t.Errorf("Expected a %s of %f, found %f", test.name, test.expected, test.found)
+		}
+	}
+
+	difference, low, high := summary.Difference(summarize([]trialOutcome{{fitness: .1}, {fitness: .1}}))
+	if math.Abs(difference-.4) > 1e-9 || math.Abs(high-difference-z95*summary.StdError) > 1e-9 ||
+		math.Abs(difference-low-z95*summary.StdError) > 1e-9 {
+		t.Errorf("Expected a difference of .4 with the same uncertainty as the mean, found %f (%f, %f)",
+			difference, low, high)
+	}
+}
+
+// TestSummarize makes sure the summary agrees with BehaviorFitness
+func TestT95(t *testing.T) {
+	expected := map[int]float64{0: 12.706205, 1: 12.706205, 4: 2.776445, 30: 2.042272, 40: 2.021075,
+		120: 1.979930, 1000000: z95}
+	for df, quantile := range expected {
+		if math.Abs(t95(df)-quantile) > 1e-5 {
+			t.Errorf("Expected t95(%d) to be %f, found %f", df, quantile, t95(df))
+		}
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	behavior := dynamicnet.NewSimpleBehavior(1, 6, .5, .1)
+	calculator := makeCalculator(11)
+	summary := calculator.Summarize(behavior)
+	fitness := calculator.BehaviorFitness(behavior)
+	if summary.NumTrials != 20 || math.Abs(summary.Mean-float64(fitness)) > 1e-5 {
+		t.Errorf("Expected the mean of 20 trials to be %f, found %f from %d", fitness, summary.Mean, summary.NumTrials)
+	}
+	if summary.ConfidenceLow > summary.Mean || summary.ConfidenceHigh < summary.Mean ||
+		summary.Min > summary.Median || summary.Median > summary.Max {
+		t.Errorf("Expected the statistics to be in order, found %+v", summary)
+	}
+	if summary.MeanPeakPrevalence <= 0 || summary.MeanTimeToPeak > summary.MeanDuration ||
+		summary.MeanDuration > 50 {
+		t.Errorf("Expected the outbreaks to peak before they end, found %+v", summary)
+	}
+	if makeCalculator(11).SummarizeGenotype(evolution.NewFloat32Genotype([]float32{1, 6, .5, .1})).Mean != summary.Mean {
+		t.Error("Expected the genotype to be summarized like its behavior")
+	}
+}
+
+// TestPairedDifference makes sure summaries from the same seed are compared trial by trial
+func TestPairedDifference(t *testing.T) {
+	calculator := makeCalculator(11)
+	rewiring := calculator.Summarize(dynamicnet.NewSimpleBehavior(1, 6, .5, .1))
+	static := calculator.Summarize(nil)
+	difference, low, high := rewiring.Difference(static)
+	if math.Abs(difference-(rewiring.Mean-static.Mean)) > 1e-9 || low > difference || high < difference {
+		t.Errorf("Expected a difference of %f inside its interval, found %f (%f, %f)",
+			rewiring.Mean-static.Mean, difference, low, high)
+	}
+
+	differences := make([]float64, rewiring.NumTrials)
+	for trial := range differences {
+		differences[trial] = rewiring.trialFitnesses[trial] - static.trialFitnesses[trial]
+	}
+	stdError := math.Sqrt(sampleVariance(differences, difference) / float64(len(differences)))
+	if math.Abs(high-difference-t95(len(differences)-1)*stdError) > 1e-9 {
+		t.Errorf("Expected the interval to come from the paired differences, found (%f, %f)", low, high)
+	}
+
+	// from a different seed the trials can't be paired
+	other := makeCalculator(12).Summarize(nil)
+	_, low, high = rewiring.Difference(other)
+	independentError := math.Sqrt(rewiring.StdError*rewiring.StdError + other.StdError*other.StdError)
+	if math.Abs(high-low-2*z95*independentError) > 1e-9 {
+		t.Errorf("Expected the summaries to be treated as independent, found (%f, %f)", low, high)
+	}
+}
+
+// TestMajorOutbreaks compares a disease that can't spread with one that always does
+func TestMajorOutbreaks(t *testing.T) {
+	network := networkgenerator.MakeCompleteNetwork(50)
+	extinct := NewNetworkFitnessCalculator(network, 10, 20, dsnet.NewBasicDisease(1, 2, 0, dsnet.NewInfectN(2)), 1)
+	summary := extinct.Summarize(nil)
+	if summary.MajorOutbreakProbability != 0 || summary.MeanDuration != 3 ||
+		math.Abs(summary.MeanPeakPrevalence-.04) > 1e-9 {
+		t.Errorf("Expected every outbreak to end after 3 steps without spreading, found %+v", summary)
+	}
+
+	major := NewNetworkFitnessCalculator(network, 10, 20, dsnet.NewBasicDisease(1, 2, 1, dsnet.NewInfectN(2)), 1)
+	summary = major.Summarize(nil)
+	if summary.MajorOutbreakProbability != 1 || summary.Mean != 0 {
+		t.Errorf("Expected every outbreak to infect everyone, found %+v", summary)
+	}
+	major.SetMajorOutbreakThreshold(1)
+	if summary = major.Summarize(nil); summary.MajorOutbreakProbability != 0 {
+		t.Errorf("Expected no outbreak to infect more than everyone, found %f", summary.MajorOutbreakProbability)
+	}
+}
@@ -0,0 +1,91 @@
+package optimized
+
+import (
+	"context"
+	"runtime"
+	"sync"
+)
+
+// ProgressFunc is called each time a trial finishes with the number of finished trials
+// and the number of trials in the batch. It is always called from a single goroutine.
+// Adaptive trials count every batch they add, so total grows with each batch.
+type ProgressFunc func(finished, total int)
+
+// SetNumWorkers sets how many trials may run at once. A value less than 1 uses GOMAXPROCS.
+func (n *NetworkFitnessCalculator) SetNumWorkers(numWorkers int) {
+	n.numWorkers = numWorkers
+}
+
+// SetProgressFunc sets the function that is told about finished trials. It may be nil.
+func (n *NetworkFitnessCalculator) SetProgressFunc(progress ProgressFunc) {
+	n.progress = progress
+}
+
+// workers gives the number of worker goroutines to use for a batch of trials
+func (n NetworkFitnessCalculator) workers() int {
+	if n.numWorkers < 1 {
+		return runtime.GOMAXPROCS(0)
+	}
+	return n.numWorkers
+}
+
+// runTrials runs trials first through first+count-1 on a bounded pool of workers. Progress is
+// reported as if they were the last of first+count trials.
+// runTrial is called from the workers and should build its own DiseasedNetwork so that
+// there are never more networks in memory than there are workers. It should store its
+// results itself, indexed by trial number.
+// The first error returned by runTrial, or the context's error, stops the batch and is returned.
+func (n NetworkFitnessCalculator) runTrials(ctx context.Context, first, count int,
+	runTrial func(ctx context.Context, trial int) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	trials := make(chan int)
+	go func() {
+		defer close(trials)
+		for trial := first; trial < first+count; trial++ {
+			select {
+			case trials <- trial:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	finished := make(chan error)
+	var wg sync.WaitGroup
+	for w := 0; w < n.workers(); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for trial := range trials {
+				finished <- runTrial(ctx, trial)
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(finished)
+	}()
+
+	// keep draining finished after an error so that no worker is left blocked
+	var firstErr error
+	numFinished := 0
+	for err := range finished {
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+				cancel()
+			}
+			continue
+		}
+		numFinished++
+		if n.progress != nil && firstErr == nil {
+			n.progress(first+numFinished, first+count)
+		}
+	}
+	if firstErr == nil && numFinished < count {
+		firstErr = ctx.Err()
+	}
+	return firstErr
+}
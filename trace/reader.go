@@ -0,0 +1,203 @@
+package trace
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Reader reads the events of a trace one at a time
+type Reader struct {
+	decoder *json.Decoder
+	header  Header
+}
+
+// NewReader reads the header of the trace in r. An error is returned if the header can't be
+// read, is for a different version of the format or is inconsistent.
+func NewReader(r io.Reader) (*Reader, error) {
+	reader := &Reader{decoder: json.NewDecoder(r)}
+	if err := reader.decoder.Decode(&reader.header); err != nil {
+		if err == io.EOF {
+			return nil, errors.New("trace is empty")
+		}
+		return nil, fmt.Errorf("can't read the trace header: %w", err)
+	}
+	header := reader.header
+	if header.Version != Version {
+		return nil, fmt.Errorf("trace is version %d but only version %d can be read", header.Version, Version)
+	}
+	if len(header.States) != len(header.Compartments) {
+		return nil, fmt.Errorf("trace has states for %d diseases but compartments for %d",
+			len(header.States), len(header.Compartments))
+	}
+	for disease, states := range header.States {
+		if len(states) != header.NumNodes {
+			return nil, fmt.Errorf("trace has states for %d nodes in disease %d but %d nodes",
+				len(states), disease, header.NumNodes)
+		}
+		for node, state := range states {
+			if state < 0 || state >= len(header.Compartments[disease]) {
+				return nil, fmt.Errorf("node %d starts in compartment %d of disease %d, which doesn't exist",
+					node, state, disease)
+			}
+		}
+	}
+	for _, edge := range header.Edges {
+		if !inRange(edge.Node1, header.NumNodes) || !inRange(edge.Node2, header.NumNodes) {
+			return nil, fmt.Errorf("trace has an edge between %d and %d, which don't both exist",
+				edge.Node1, edge.Node2)
+		}
+	}
+	return reader, nil
+}
+
+// Header returns the header of the trace
+func (r *Reader) Header() Header {
+	return r.header
+}
+
+// Next returns the next event in the trace. It returns io.EOF after the last event.
+func (r *Reader) Next() (Event, error) {
+	var event Event
+	if err := r.decoder.Decode(&event); err != nil {
+		if err == io.EOF {
+			return Event{}, io.EOF
+		}
+		return Event{}, fmt.Errorf("can't read trace event: %w", err)
+	}
+	switch event.Kind {
+	case KindState, KindInfection, KindEdgeAdded, KindEdgeRemoved, KindStep, KindEnd:
+		return event, nil
+	}
+	return Event{}, fmt.Errorf("unknown trace event kind %q", event.Kind)
+}
+
+// Replay rebuilds the states of the nodes and the edges of the network from a trace.
+// It starts from the header and moves forward one event at a time.
+type Replay struct {
+	compartments [][]string
+	states       [][]uint8
+	// neighbors maps the neighbors of each node to the weights of the edges to them
+	neighbors []map[int]uint8
+	step      uint
+	ended     bool
+}
+
+// NewReplay returns a Replay at the start of the trace described by header
+func NewReplay(header Header) *Replay {
+	replay := &Replay{
+		compartments: header.Compartments,
+		states:       make([][]uint8, len(header.States)),
+		neighbors:    make([]map[int]uint8, header.NumNodes),
+	}
+	for disease, states := range header.States {
+		replay.states[disease] = make([]uint8, len(states))
+		for node, state := range states {
+			replay.states[disease][node] = uint8(state)
+		}
+	}
+	for node := range replay.neighbors {
+		replay.neighbors[node] = make(map[int]uint8)
+	}
+	for _, edge := range header.Edges {
+		replay.neighbors[edge.Node1][edge.Node2] = edge.Weight
+		replay.neighbors[edge.Node2][edge.Node1] = edge.Weight
+	}
+	return replay
+}
+
+// Apply moves the replay forward by event. An error is returned if event doesn't fit with the
+// events before it, like a node leaving a compartment it isn't in or an edge being removed
+// that doesn't exist. The replay isn't changed in that case.
+func (r *Replay) Apply(event Event) error {
+	if r.ended {
+		return errors.New("trace continues after its end")
+	}
+	switch event.Kind {
+	case KindState:
+		if !r.validDisease(event.Disease) || !inRange(event.Node, len(r.neighbors)) ||
+			int(event.To) >= len(r.compartments[event.Disease]) {
+			return fmt.Errorf("step %d: state event refers to something that doesn't exist", event.Step)
+		}
+		if r.states[event.Disease][event.Node] != event.From {
+			return fmt.Errorf("step %d: node %d left compartment %d of disease %d, but it was in %d",
+				event.Step, event.Node, event.From, event.Disease, r.states[event.Disease][event.Node])
+		}
+		r.states[event.Disease][event.Node] = event.To
+	case KindInfection:
+		if !r.validDisease(event.Disease) || !inRange(event.Infector, len(r.neighbors)) ||
+			!inRange(event.Infected, len(r.neighbors)) {
+			return fmt.Errorf("step %d: infection event refers to something that doesn't exist", event.Step)
+		}
+	case KindEdgeAdded:
+		if !r.validEdge(event) {
+			return fmt.Errorf("step %d: edge event refers to a node that doesn't exist", event.Step)
+		}
+		if _, ok := r.neighbors[event.Node1][event.Node2]; ok {
+			return fmt.Errorf("step %d: edge between %d and %d was added twice", event.Step, event.Node1, event.Node2)
+		}
+		r.neighbors[event.Node1][event.Node2] = event.Weight
+		r.neighbors[event.Node2][event.Node1] = event.Weight
+	case KindEdgeRemoved:
+		if !r.validEdge(event) {
+			return fmt.Errorf("step %d: edge event refers to a node that doesn't exist", event.Step)
+		}
+		if _, ok := r.neighbors[event.Node1][event.Node2]; !ok {
+			return fmt.Errorf("step %d: edge between %d and %d was removed but doesn't exist",
+				event.Step, event.Node1, event.Node2)
+		}
+		delete(r.neighbors[event.Node1], event.Node2)
+		delete(r.neighbors[event.Node2], event.Node1)
+	case KindStep:
+		r.step = event.Step
+	case KindEnd:
+		r.ended = true
+	default:
+		return fmt.Errorf("unknown trace event kind %q", event.Kind)
+	}
+	return nil
+}
+
+func (r *Replay) validDisease(disease int) bool {
+	return inRange(disease, len(r.states))
+}
+
+func (r *Replay) validEdge(event Event) bool {
+	return inRange(event.Node1, len(r.neighbors)) && inRange(event.Node2, len(r.neighbors))
+}
+
+// inRange reports whether 0 <= i < n
+func inRange(i, n int) bool {
+	return i >= 0 && i < n
+}
+
+// Step returns the last step that has been completely replayed
+func (r *Replay) Step() uint {
+	return r.step
+}
+
+// Ended reports whether the end of the trace has been replayed
+func (r *Replay) Ended() bool {
+	return r.ended
+}
+
+// State returns the state of node in the disease at diseaseIndex
+func (r *Replay) State(diseaseIndex, node int) uint8 {
+	return r.states[diseaseIndex][node]
+}
+
+// NodeStates returns a copy of the state of every node in the disease at diseaseIndex
+func (r *Replay) NodeStates(diseaseIndex int) []uint8 {
+	return append([]uint8(nil), r.states[diseaseIndex]...)
+}
+
+// EdgeWeight returns the weight of the edge between node1 and node2, or 0 if there isn't one
+func (r *Replay) EdgeWeight(node1, node2 int) uint8 {
+	return r.neighbors[node1][node2]
+}
+
+// Degree returns the number of neighbors node has
+func (r *Replay) Degree(node int) int {
+	return len(r.neighbors[node])
+}
@@ -0,0 +1,215 @@
+// Package trace records simulations of a diseasednetwork.DiseasedNetwork so that they can be
+// replayed, compared and analyzed without running them again.
+//
+// A trace is a JSON Lines file. The first line is a Header with the version of the format, the
+// compartments of each disease, the state of every node after the initial infections and the
+// edges of the network. Every line after that is an Event. The events of each step are followed
+// by a step event and the trace ends with an end event. Traces of the same simulation are
+// identical, so they can be compared line by line.
+package trace
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+
+	dsnet "github.com/GaudiestTooth17/infection-resistant-network/diseasednetwork"
+)
+
+// Version is the version of the format written by Writer
+const Version = 1
+
+// the kinds of events
+const (
+	// KindState is a node moving to a different compartment of a disease
+	KindState = "state"
+	// KindInfection is a node passing a disease on to one of its neighbors. It comes right after
+	// the state event of the newly infected node.
+	KindInfection = "infection"
+	// KindEdgeAdded is an edge being added to the network
+	KindEdgeAdded = "edgeAdded"
+	// KindEdgeRemoved is an edge being removed from the network
+	KindEdgeRemoved = "edgeRemoved"
+	// KindStep marks the end of a step
+	KindStep = "step"
+	// KindEnd marks the end of the trace
+	KindEnd = "end"
+)
+
+// Header describes the start of a simulation
+type Header struct {
+	Version  int `json:"version"`
+	NumNodes int `json:"numNodes"`
+	// Compartments holds the names of the compartments of each disease
+	Compartments [][]string `json:"compartments"`
+	// States holds the state of every node in each disease after the initial infections
+	States [][]int `json:"states"`
+	// Edges are the edges the network starts with, with the lower numbered node first
+	Edges []Edge `json:"edges"`
+}
+
+// Edge is an edge in a network
+type Edge struct {
+	Node1  int   `json:"node1"`
+	Node2  int   `json:"node2"`
+	Weight uint8 `json:"weight"`
+}
+
+// Event is something that happened during a simulation. Kind says which of the fields are used.
+// Step is the step the event happened in, counting from 1.
+type Event struct {
+	Kind string `json:"kind"`
+	Step uint   `json:"step"`
+	// Disease is used by state and infection events
+	Disease int `json:"disease"`
+	// Node, From and To are used by state events
+	Node int   `json:"node"`
+	From uint8 `json:"from"`
+	To   uint8 `json:"to"`
+	// Infector and Infected are used by infection events
+	Infector int `json:"infector"`
+	Infected int `json:"infected"`
+	// Node1 and Node2 are used by edge events and Weight by edgeAdded events
+	Node1  int   `json:"node1"`
+	Node2  int   `json:"node2"`
+	Weight uint8 `json:"weight"`
+}
+
+// the records written for each kind of event leave out the fields the kind doesn't use
+
+type stateRecord struct {
+	Kind    string `json:"kind"`
+	Step    uint   `json:"step"`
+	Disease int    `json:"disease"`
+	Node    int    `json:"node"`
+	From    uint8  `json:"from"`
+	To      uint8  `json:"to"`
+}
+
+type infectionRecord struct {
+	Kind     string `json:"kind"`
+	Step     uint   `json:"step"`
+	Disease  int    `json:"disease"`
+	Infector int    `json:"infector"`
+	Infected int    `json:"infected"`
+}
+
+type edgeAddedRecord struct {
+	Kind   string `json:"kind"`
+	Step   uint   `json:"step"`
+	Node1  int    `json:"node1"`
+	Node2  int    `json:"node2"`
+	Weight uint8  `json:"weight"`
+}
+
+type edgeRemovedRecord struct {
+	Kind  string `json:"kind"`
+	Step  uint   `json:"step"`
+	Node1 int    `json:"node1"`
+	Node2 int    `json:"node2"`
+}
+
+type stepRecord struct {
+	Kind string `json:"kind"`
+	Step uint   `json:"step"`
+}
+
+// Writer is an observer that writes a trace of the simulation it watches. Add it to a
+// DiseasedNetwork with dsnet.WithObservers and call Finish on the network after the last step.
+// Writes are buffered. The first error stops the writer and is returned by Err.
+type Writer struct {
+	buffer  *bufio.Writer
+	encoder *json.Encoder
+	step    uint
+	err     error
+}
+
+var _ dsnet.EdgeObserver = &Writer{}
+
+// NewWriter returns a Writer that writes a trace to w
+func NewWriter(w io.Writer) *Writer {
+	buffer := bufio.NewWriter(w)
+	return &Writer{buffer: buffer, encoder: json.NewEncoder(buffer)}
+}
+
+// write writes record on its own line unless there has already been an error
+func (w *Writer) write(record interface{}) {
+	if w.err == nil {
+		w.err = w.encoder.Encode(record)
+	}
+}
+
+// RunStarted writes the header
+func (w *Writer) RunStarted(n *dsnet.DiseasedNetwork) {
+	header := Header{
+		Version:      Version,
+		NumNodes:     n.NumNodes(),
+		Compartments: make([][]string, n.NumDiseases()),
+		States:       make([][]int, n.NumDiseases()),
+		Edges:        make([]Edge, 0),
+	}
+	for disease := range header.Compartments {
+		for _, compartment := range n.Compartments(disease) {
+			header.Compartments[disease] = append(header.Compartments[disease], compartment.Name)
+		}
+		states := n.GetNodeStates(disease)
+		header.States[disease] = make([]int, len(states))
+		for node, state := range states {
+			header.States[disease][node] = int(state)
+		}
+	}
+	for node := 0; node < n.NumNodes(); node++ {
+		weights := n.NeighborWeights(node)
+		for i, neighbor := range n.Neighbors(node) {
+			if int(neighbor) > node {
+				header.Edges = append(header.Edges, Edge{Node1: node, Node2: int(neighbor), Weight: weights[i]})
+			}
+		}
+	}
+	w.write(header)
+}
+
+// StateChanged writes a state event
+func (w *Writer) StateChanged(diseaseIndex, node int, from, to uint8, step uint) {
+	w.write(stateRecord{Kind: KindState, Step: step, Disease: diseaseIndex, Node: node, From: from, To: to})
+}
+
+// Infected writes an infection event
+func (w *Writer) Infected(diseaseIndex, infector, infected int, step uint) {
+	w.write(infectionRecord{Kind: KindInfection, Step: step, Disease: diseaseIndex,
+		Infector: infector, Infected: infected})
+}
+
+// EdgeAdded writes an edgeAdded event
+func (w *Writer) EdgeAdded(node1, node2 int, weight uint8, step uint) {
+	w.write(edgeAddedRecord{Kind: KindEdgeAdded, Step: step, Node1: node1, Node2: node2, Weight: weight})
+}
+
+// EdgeRemoved writes an edgeRemoved event
+func (w *Writer) EdgeRemoved(node1, node2 int, step uint) {
+	w.write(edgeRemovedRecord{Kind: KindEdgeRemoved, Step: step, Node1: node1, Node2: node2})
+}
+
+// StepEnded writes a step event
+func (w *Writer) StepEnded(n *dsnet.DiseasedNetwork, step uint) {
+	w.step = step
+	w.write(stepRecord{Kind: KindStep, Step: step})
+}
+
+// RunEnded writes the end event and flushes the trace
+func (w *Writer) RunEnded(n *dsnet.DiseasedNetwork) {
+	w.write(stepRecord{Kind: KindEnd, Step: w.step})
+	w.Flush()
+}
+
+// Flush writes any buffered events
+func (w *Writer) Flush() {
+	if w.err == nil {
+		w.err = w.buffer.Flush()
+	}
+}
+
+// Err returns the first error the writer ran into
+func (w *Writer) Err() error {
+	return w.err
+}
@@ -0,0 +1,146 @@
+package trace
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	dsnet "github.com/GaudiestTooth17/infection-resistant-network/diseasednetwork"
+	"github.com/GaudiestTooth17/infection-resistant-network/dynamicnet"
+	"github.com/GaudiestTooth17/infection-resistant-network/networkgenerator"
+)
+
+// runTraced runs a simulation with a bad and a good disease on a rewiring network for numSteps
+// steps and returns the network along with its trace
+func runTraced(t *testing.T, seed int64, numSteps int) (dsnet.DiseasedNetwork, []byte) {
+	network := networkgenerator.MakeWattsStrogatz(100, 4, .1, 2)
+	var buffer bytes.Buffer
+	writer := NewWriter(&buffer)
+	net := dsnet.New(&network, []dsnet.Disease{
+		dsnet.NewBasicDisease(1, 3, .4, dsnet.NewInfectN(3)),
+		dsnet.NewGoodDisease(2, 2, .3, dsnet.NewInfectN(5)),
+	}, dsnet.WithSeed(seed), dsnet.WithObservers(writer),
+		dsnet.WithBehavior(dynamicnet.NewSimpleBehavior(1, 8, .5, .3)))
+	for step := 0; step < numSteps; step++ {
+		net.Step()
+	}
+	net.Finish()
+	if writer.Err() != nil {
+		t.Fatal(writer.Err())
+	}
+	return net, buffer.Bytes()
+}
+
+// TestReplay makes sure replaying a trace ends up where the simulation did
+func TestReplay(t *testing.T) {
+	net, trace := runTraced(t, 4, 30)
+	reader, err := NewReader(bytes.NewReader(trace))
+	if err != nil {
+		t.Fatal(err)
+	}
+	replay := NewReplay(reader.Header())
+	numInfections := 0
+	numEdgeEvents := 0
+	for {
+		event, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := replay.Apply(event); err != nil {
+			t.Fatal(err)
+		}
+		if event.Kind == KindInfection && event.Disease == 0 {
+			numInfections++
+		}
+		if event.Kind == KindEdgeAdded || event.Kind == KindEdgeRemoved {
+			numEdgeEvents++
+		}
+	}
+
+	if !replay.Ended() || replay.Step() != 30 {
+		t.Errorf("Expected the replay to end after step 30, found %t and %d", replay.Ended(), replay.Step())
+	}
+	for disease := 0; disease < 2; disease++ {
+		if !bytes.Equal(replay.NodeStates(disease), net.GetNodeStates(disease)) {
+			t.Errorf("Expected the replayed states of disease %d to match the simulation", disease)
+		}
+	}
+	for node := 0; node < net.NumNodes(); node++ {
+		if replay.Degree(node) != net.Degree(node) {
+			t.Errorf("Expected node %d to have degree %d, found %d", node, net.Degree(node), replay.Degree(node))
+		}
+		for _, neighbor := range net.Neighbors(node) {
+			if replay.EdgeWeight(node, int(neighbor)) != 1 {
+				t.Errorf("Expected the replay to have an edge between %d and %d", node, neighbor)
+			}
+		}
+	}
+	expectedInfections := 0
+	for _, c := range net.InfectionTree(0).Cases() {
+		if c.Infector >= 0 {
+			expectedInfections++
+		}
+	}
+	if numInfections != expectedInfections || numInfections == 0 {
+		t.Errorf("Expected %d infections, found %d", expectedInfections, numInfections)
+	}
+	if numEdgeEvents == 0 {
+		t.Error("Expected the network to be rewired")
+	}
+}
+
+// TestTracesAreReproducible makes sure traces of the same simulation can be compared
+func TestTracesAreReproducible(t *testing.T) {
+	_, trace1 := runTraced(t, 9, 10)
+	_, trace2 := runTraced(t, 9, 10)
+	if !bytes.Equal(trace1, trace2) {
+		t.Error("Expected identical traces from the same seed")
+	}
+	lines := strings.Split(strings.TrimSpace(string(trace1)), "\n")
+	if lines[len(lines)-1] != `{"kind":"end","step":10}` {
+		t.Errorf("Expected the trace to finish with an end event, found %s", lines[len(lines)-1])
+	}
+}
+
+func TestInvalidTraces(t *testing.T) {
+	headers := map[string]string{
+		"empty":              "",
+		"newer version":      `{"version":2,"numNodes":1,"compartments":[["S"]],"states":[[0]],"edges":[]}`,
+		"missing states":     `{"version":1,"numNodes":2,"compartments":[["S"]],"states":[[0]],"edges":[]}`,
+		"unknown state":      `{"version":1,"numNodes":1,"compartments":[["S"]],"states":[[3]],"edges":[]}`,
+		"edge to no one":     `{"version":1,"numNodes":1,"compartments":[["S"]],"states":[[0]],"edges":[{"node1":0,"node2":1,"weight":1}]}`,
+		"malformed":          `{"version":1,`,
+		"states for nothing": `{"version":1,"numNodes":1,"compartments":[],"states":[[0]],"edges":[]}`,
+	}
+	for name, header := range headers {
+		if _, err := NewReader(strings.NewReader(header)); err == nil {
+			t.Errorf("Expected a trace with a header that is %s to be an error", name)
+		}
+	}
+
+	header := `{"version":1,"numNodes":2,"compartments":[["S","I"]],"states":[[0,1]],"edges":[{"node1":0,"node2":1,"weight":1}]}`
+	events := map[string]string{
+		"wrong compartment": `{"kind":"state","step":1,"disease":0,"node":1,"from":0,"to":1}`,
+		"missing node":      `{"kind":"state","step":1,"disease":0,"node":2,"from":0,"to":1}`,
+		"duplicate edge":    `{"kind":"edgeAdded","step":1,"node1":1,"node2":0,"weight":1}`,
+		"missing edge":      `{"kind":"edgeRemoved","step":1,"node1":0,"node2":0}`,
+		"unknown kind":      `{"kind":"teleport","step":1}`,
+	}
+	for name, line := range events {
+		reader, err := NewReader(strings.NewReader(header + "\n" + line + "\n"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		event, err := reader.Next()
+		if err == nil {
+			err = NewReplay(reader.Header()).Apply(event)
+		}
+		if err == nil {
+			t.Errorf("Expected an event with a %s to be an error", name)
+		}
+	}
+}